@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeValid - reports if the spec is a valid configuration
+	ConditionTypeValid = "Valid"
+	// ConditionTypeApplied - reports if the spec is the active configuration
+	ConditionTypeApplied = "Applied"
+
+	ReasonConfigValid          = "ConfigValid"
+	ReasonConfigInvalid        = "ConfigInvalid"
+	ReasonConfigApplied        = "ConfigApplied"
+	ReasonPreviousConfigActive = "PreviousConfigActive"
+	ReasonConfigNotApplied     = "ConfigNotApplied"
+	ReasonNoConfigActive       = "NoConfigActive"
+)
+
+// BootstrapperConfigSpec defines the configuration of the rt-bootstrapper; it
+// has the same content as the configuration document
+type BootstrapperConfigSpec struct {
+	apiv1.Config `json:",inline"`
+}
+
+// BootstrapperConfigStatus defines the observed state of BootstrapperConfig.
+type BootstrapperConfigStatus struct {
+	// ObservedGeneration - the generation of the spec the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastError - the error returned while loading the spec, empty if the spec is valid
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// Conditions - the Valid and Applied conditions of the configuration
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="Applied",type=string,JSONPath=`.status.conditions[?(@.type=="Applied")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BootstrapperConfig is the Schema for the bootstrapperconfigs API.
+type BootstrapperConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BootstrapperConfigSpec   `json:"spec,omitempty"`
+	Status BootstrapperConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BootstrapperConfigList contains a list of BootstrapperConfig.
+type BootstrapperConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BootstrapperConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BootstrapperConfig{}, &BootstrapperConfigList{})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceBootstrapPolicyName - the name of the policy the pod defaulters
+// read in every namespace
+const NamespaceBootstrapPolicyName = "default"
+
+// EnvVar - an environment variable added to all containers of the namespace
+type EnvVar struct {
+	// Name - the name of the environment variable
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z][-._a-zA-Z0-9]*$`
+	Name string `json:"name"`
+	// Value - the value of the environment variable
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// NamespaceBootstrapPolicySpec defines the settings the namespace owners choose
+// for the pods of their namespace; only the settings allowed by the tenant
+// policy of the cluster configuration are applied
+type NamespaceBootstrapPolicySpec struct {
+	// Features - enables (true) or disables (false) the features for the pods
+	// in the namespace; the pod and the namespace annotations take precedence
+	// +optional
+	Features map[string]bool `json:"features,omitempty"`
+
+	// ClusterTrustBundle - the name of the trust bundle mounted instead of the
+	// default one
+	// +optional
+	ClusterTrustBundle string `json:"clusterTrustBundle,omitempty"`
+
+	// Env - environment variables added to all containers in the namespace;
+	// variables already defined by the containers are not overridden
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Env []EnvVar `json:"env,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=nbp
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the policy of the namespace must be named 'default'"
+
+// NamespaceBootstrapPolicy is the Schema for the namespacebootstrappolicies API.
+type NamespaceBootstrapPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NamespaceBootstrapPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceBootstrapPolicyList contains a list of NamespaceBootstrapPolicy.
+type NamespaceBootstrapPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceBootstrapPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceBootstrapPolicy{}, &NamespaceBootstrapPolicyList{})
+}
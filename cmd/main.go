@@ -17,14 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"path"
 	"time"
 
+	"github.com/kyma-project/rt-bootstrapper/internal/config"
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/kyma-project/rt-bootstrapper/internal/registry"
 	"github.com/kyma-project/rt-bootstrapper/internal/webhook/certificate"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,16 +39,20 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	webhook "github.com/kyma-project/rt-bootstrapper/internal/webhook/server"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	//"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
 	"github.com/kyma-project/rt-bootstrapper/internal/controller"
 	webhook_v1 "github.com/kyma-project/rt-bootstrapper/internal/webhook/v1"
 	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
@@ -55,7 +64,11 @@ const (
 	webhookServerKeyName     = "tls.key"
 	webhookServerCertName    = "tls.crt"
 	flagWebhookName          = "webhook-name"
-	configFilePath           = "/rt-bootstrapper-config.json"
+	flagValidatingWebhook    = "validating-webhook-name"
+	configFilePath           = "/etc/rt-bootstrapper/rt-bootstrapper-config.yaml"
+	configSourceFile         = "file"
+	configSourceConfigMap    = "configmap"
+	configSourceCRD          = "crd"
 	patchFieldManagerName    = "rt-bootstrapper-webhook"
 )
 
@@ -66,6 +79,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(rtbootstrapperv1alpha1.AddToScheme(scheme))
 
 	// +kubebuilder:scaffold:scheme
 }
@@ -75,9 +89,85 @@ func readConfig(name string) (*apiv1.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = file.Close() }()
 	return apiv1.NewConfig(file)
 }
 
+// readConfigMap - returns the configuration stored in the config map and the
+// content it was decoded from
+func readConfigMap(ctx context.Context, c client.Client, name types.NamespacedName, key string) (*apiv1.Config, []byte, error) {
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, name, &cm); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := controller.ConfigMapData(&cm, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := apiv1.NewConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, data, nil
+}
+
+func readBootstrapperConfig(ctx context.Context, c client.Client, name string) (*apiv1.Config, error) {
+	var bc rtbootstrapperv1alpha1.BootstrapperConfig
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, &bc); err != nil {
+		return nil, err
+	}
+
+	data, err := apiv1.MarshalConfig(bc.Spec.Config)
+	if err != nil {
+		return nil, err
+	}
+	return apiv1.NewConfig(bytes.NewReader(data))
+}
+
+// pullSecretTargets - returns the master secrets replicated by the secret controller
+func pullSecretTargets(cfg *apiv1.Config) []types.NamespacedName {
+	if cfg == nil {
+		return nil
+	}
+
+	secrets := cfg.PullSecrets()
+	result := make([]types.NamespacedName, 0, len(secrets))
+	for _, secret := range secrets {
+		result = append(result, types.NamespacedName{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		})
+	}
+	return result
+}
+
+// secretSyncInterval - returns the interval of the pull secret
+// synchronization, zero if no configuration is active
+func secretSyncInterval(cfg *apiv1.Config) time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(cfg.SecretSyncInterval)
+}
+
+// mirrorProbe - returns the mirrors probed by the health prober and the
+// probe settings, no mirrors if the failover is disabled
+func mirrorProbe(cfg *apiv1.Config) ([]string, registry.HealthProberOpts) {
+	if cfg == nil || cfg.MirrorFailover == nil {
+		return nil, registry.HealthProberOpts{}
+	}
+
+	return cfg.ProbedMirrors(), registry.HealthProberOpts{
+		Interval:         cfg.MirrorFailover.IntervalOrDefault(),
+		Timeout:          cfg.MirrorFailover.TimeoutOrDefault(),
+		FailureThreshold: cfg.MirrorFailover.FailureThresholdOrDefault(),
+		SuccessThreshold: cfg.MirrorFailover.SuccessThresholdOrDefault(),
+		PlainHTTP:        cfg.MirrorFailover.PlainHTTPRegistries,
+	}
+}
+
 // nolint:gocyclo
 func main() {
 	var metricsAddr string
@@ -88,7 +178,12 @@ func main() {
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 
-	var webhookCfgName string
+	var webhookCfgName, validatingWebhookCfgName string
+	var configReloadInterval time.Duration
+	var configSource, configPath string
+	var configMapName, configMapNamespace, configMapKey string
+	var bootstrapperConfigName string
+	var configFileFallback bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -101,6 +196,24 @@ func main() {
 	flag.StringVar(&webhookCertKey, "webhook-cert-key", webhookServerKeyName, "The name of the webhook key file.")
 
 	flag.StringVar(&webhookCfgName, flagWebhookName, "rt-bootstrapper-mutating-webhook-configuration", "The name of the mutating webhook configuration to be updated.")
+	flag.StringVar(&validatingWebhookCfgName, flagValidatingWebhook, "rt-bootstrapper-validating-webhook-configuration",
+		"The name of the validating webhook configuration to be updated, leave empty to skip the update.")
+
+	flag.StringVar(&configSource, "config-source", configSourceFile,
+		"The source of the configuration, one of 'file', 'configmap' or 'crd'.")
+	flag.StringVar(&configPath, "config-path", configFilePath, "The path of the configuration file.")
+	flag.DurationVar(&configReloadInterval, "config-reload-interval", 10*time.Second,
+		"The interval in which the configuration file is checked for changes.")
+	flag.StringVar(&configMapName, "config-map-name", "rt-bootstrapper-config",
+		"The name of the config map that contains the configuration.")
+	flag.StringVar(&configMapNamespace, "config-map-namespace", "kyma-system",
+		"The namespace of the config map that contains the configuration.")
+	flag.StringVar(&configMapKey, "config-map-key", "rt-bootstrapper-config.yaml",
+		"The key of the config map entry that contains the configuration.")
+	flag.StringVar(&bootstrapperConfigName, "bootstrapper-config-name", "default",
+		"The name of the BootstrapperConfig that contains the configuration.")
+	flag.BoolVar(&configFileFallback, "config-file-fallback", false,
+		"If set, the configuration file is used when the config map or the BootstrapperConfig cannot be read on startup.")
 
 	flag.StringVar(&metricsCertPath, "metrics-cert-path", "",
 		"The directory that contains the metrics server certificate.")
@@ -119,12 +232,6 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	cfg, err := readConfig(configFilePath)
-	if err != nil {
-		setupLog.Error(err, "unable to start manager")
-		os.Exit(1)
-	}
-
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -150,6 +257,47 @@ func main() {
 		os.Exit(1)
 	}
 
+	configMap := types.NamespacedName{
+		Name:      configMapName,
+		Namespace: configMapNamespace,
+	}
+
+	var cfg *apiv1.Config
+	// the content of the config map the configuration was loaded from, empty
+	// if it was loaded from another source
+	var configMapContent []byte
+	switch configSource {
+	case configSourceFile:
+		cfg, err = readConfig(configPath)
+	case configSourceConfigMap:
+		cfg, configMapContent, err = readConfigMap(context.Background(), rtClient, configMap, configMapKey)
+		if err != nil && configFileFallback {
+			setupLog.Error(err, "unable to read configuration from config map, falling back to file",
+				"config-map", configMap, "config-path", configPath)
+			cfg, err = readConfig(configPath)
+		}
+	case configSourceCRD:
+		cfg, err = readBootstrapperConfig(context.Background(), rtClient, bootstrapperConfigName)
+		if err != nil && configFileFallback {
+			setupLog.Error(err, "unable to read configuration from bootstrapper config, falling back to file",
+				"bootstrapper-config", bootstrapperConfigName, "config-path", configPath)
+			cfg, err = readConfig(configPath)
+		}
+		// the bootstrapper config controller applies the configuration once a
+		// valid one is created and reports the errors in its status
+		if err != nil && !configFileFallback {
+			setupLog.Error(err, "unable to read configuration from bootstrapper config, defaulters disabled",
+				"bootstrapper-config", bootstrapperConfigName)
+			cfg, err = nil, nil
+		}
+	default:
+		err = fmt.Errorf("unsupported configuration source: %s", configSource)
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to read configuration")
+		os.Exit(1)
+	}
+
 	if len(webhookCertPath) == 0 {
 		setupLog.Info("Cannot start webhook server without certificate path")
 		os.Exit(1)
@@ -184,6 +332,24 @@ func main() {
 				setupLog.Error(err, "unable to patch mutating webhook configuration")
 				os.Exit(1)
 			}
+
+			if validatingWebhookCfgName == "" {
+				return
+			}
+
+			updateValidatingCABundle := certificate.BuildUpdateValidatingCABundle(
+				context.Background(),
+				rtClient,
+				certificate.BuildUpdateCABundleOpts{
+					Name:         validatingWebhookCfgName,
+					CABundle:     data,
+					FieldManager: patchFieldManagerName,
+				})
+
+			if err := retry.RetryOnConflict(retry.DefaultBackoff, updateValidatingCABundle); err != nil {
+				setupLog.Error(err, "unable to patch validating webhook configuration")
+				os.Exit(1)
+			}
 		},
 	})
 
@@ -217,8 +383,17 @@ func main() {
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "1d97a37c.kyma-project.io",
+		// only the configuration config map has to be cached
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.ConfigMap{}: {
+					Namespaces: map[string]cache.Config{configMapNamespace: {}},
+					Field:      fields.OneTermEqualSelector("metadata.name", configMapName),
+				},
+			},
+		},
+		LeaderElection:   enableLeaderElection,
+		LeaderElectionID: "1d97a37c.kyma-project.io",
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -236,25 +411,78 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := webhook_v1.SetupPodWebhookWithManager(mgr, cfg); err != nil {
+	// the registries of the master pull secrets, refreshed by the secret
+	// controller and used by the webhook to choose the pull secrets
+	creds := credentials.NewStore()
+
+	// the health of the mirrors the fallback mirrors of the overrides are
+	// chosen by
+	mirrorHealth := registry.NewHealthProber(nil)
+	mirrorHealth.SetTargets(mirrorProbe(cfg))
+	if err := mgr.Add(mirrorHealth); err != nil {
+		setupLog.Error(err, "unable to set up mirror health prober")
+		os.Exit(1)
+	}
+
+	podWebhook, err := webhook_v1.SetupPodWebhookWithManager(mgr, cfg, creds, mirrorHealth)
+	if err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
 		os.Exit(1)
 	}
 
-	if err := (&controller.SecretReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		NamespacedName: types.NamespacedName{
-			Name:      cfg.ImagePullSecretName,
-			Namespace: cfg.ImagePullSecretNamespace,
-		},
-		SecretSyncInterval: time.Duration(cfg.SecretSyncInterval),
-	}).SetupWithManager(mgr); err != nil {
+	secretReconciler := &controller.SecretReconciler{
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Targets:            pullSecretTargets(cfg),
+		SecretSyncInterval: secretSyncInterval(cfg),
+		Credentials:        creds,
+	}
+	if err := secretReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Secret")
 		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder
 
+	cfgStore := config.NewStore(cfg)
+	cfgStore.Subscribe(podWebhook.Update)
+	cfgStore.Subscribe(func(cfg *apiv1.Config) (config.Commit, error) {
+		return func() {
+			secretReconciler.SetTargets(pullSecretTargets(cfg), time.Duration(cfg.SecretSyncInterval))
+		}, nil
+	})
+	cfgStore.Subscribe(func(cfg *apiv1.Config) (config.Commit, error) {
+		return func() {
+			mirrorHealth.SetTargets(mirrorProbe(cfg))
+		}, nil
+	})
+
+	switch configSource {
+	case configSourceConfigMap:
+		err = (&controller.ConfigMapReconciler{
+			Client:         mgr.GetClient(),
+			NamespacedName: configMap,
+			Key:            configMapKey,
+			Store:          cfgStore,
+			InitialContent: configMapContent,
+		}).SetupWithManager(mgr)
+	case configSourceCRD:
+		err = (&controller.BootstrapperConfigReconciler{
+			Client: mgr.GetClient(),
+			Name:   bootstrapperConfigName,
+			Store:  cfgStore,
+		}).SetupWithManager(mgr)
+	default:
+		err = mgr.Add(&config.FileWatcher{
+			Path:     configPath,
+			Interval: configReloadInterval,
+			Store:    cfgStore,
+		})
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to set up configuration watcher", "config-source", configSource)
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", webhookServer.StartedChecker()); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
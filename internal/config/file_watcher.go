@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const defaultFileWatchInterval = 10 * time.Second
+
+var _ manager.Runnable = &FileWatcher{}
+
+// FileWatcher - polls the configuration file and loads it into the store
+// whenever its content changes. Polling is used instead of inotify because the
+// kubelet updates mounted ConfigMaps by swapping symlinks.
+type FileWatcher struct {
+	Path     string
+	Interval time.Duration
+	Store    *Store
+
+	lastContent []byte
+}
+
+// Start - implements manager.Runnable; blocks until the context is done
+func (w *FileWatcher) Start(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultFileWatchInterval
+	}
+
+	log := slog.Default().With("log-id", "config-file-watcher", "path", w.Path)
+	log.Info("watching configuration file", "interval", interval)
+
+	// the store is initialized with the content of the file; remember it so
+	// the very first tick does not reload the same configuration
+	if data, err := os.ReadFile(w.Path); err == nil {
+		w.lastContent = data
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.sync(log)
+		}
+	}
+}
+
+func (w *FileWatcher) sync(log *slog.Logger) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		log.Error("unable to read configuration file", "error", err)
+		return
+	}
+
+	if bytes.Equal(data, w.lastContent) {
+		return
+	}
+	// remember the content even if it is invalid to report the error only once
+	w.lastContent = data
+
+	log.Info("configuration file changed, reloading")
+	// the store reports and keeps track of invalid configurations
+	_ = w.Store.Load(bytes.NewReader(data))
+}
+
+// NeedLeaderElection - implements manager.LeaderElectionRunnable; every
+// replica serves admission requests so each of them has to reload
+func (w *FileWatcher) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+)
+
+// Listener - prepares the switch to a new, valid configuration; returns the
+// commit of the switch or an error if it cannot apply the configuration. The
+// commits can not fail, so the configuration is applied by all the listeners
+// or by none of them.
+type Listener = func(*apiv1.Config) (Commit, error)
+
+// Commit - switches the listener to the configuration it was prepared for
+type Commit = func()
+
+// ErrNotApplied - reports a valid configuration that some of the listeners
+// could not apply; the active configuration stays in place
+var ErrNotApplied = errors.New("configuration not applied")
+
+// Store - keeps the active configuration and propagates its changes to the
+// registered listeners. An invalid configuration never replaces the active one.
+type Store struct {
+	// updateMu serializes configuration updates so listeners observe them in order
+	updateMu  sync.Mutex
+	mu        sync.RWMutex
+	current   *apiv1.Config
+	lastErr   error
+	listeners []Listener
+	log       *slog.Logger
+}
+
+// NewStore - returns the store with the given active configuration, nil if no
+// configuration is active yet
+func NewStore(cfg *apiv1.Config) *Store {
+	return &Store{
+		current: cfg,
+		log:     slog.Default().With("log-id", "config-store"),
+	}
+}
+
+// Get - returns the active configuration, nil if none was applied yet
+func (s *Store) Get() *apiv1.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// LastError - returns the error of the last rejected configuration or nil if
+// the last configuration was applied successfully
+func (s *Store) LastError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+// Subscribe - registers the listener that will be invoked on every
+// configuration change
+func (s *Store) Subscribe(l Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+}
+
+// Load - reads, validates and applies the configuration; the active
+// configuration stays in place if the new one is invalid
+func (s *Store) Load(r io.Reader) error {
+	cfg, err := apiv1.NewConfig(r)
+	if err != nil {
+		err = fmt.Errorf("invalid configuration: %w", err)
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+
+		s.log.Error("configuration rejected, keeping the active one", "error", err)
+		return err
+	}
+
+	return s.Set(cfg)
+}
+
+// Set - applies the already validated configuration; the listeners are
+// switched to it only if all of them could prepare the switch, otherwise their
+// errors are reported wrapped in ErrNotApplied and the active configuration
+// stays in place
+func (s *Store) Set(cfg *apiv1.Config) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.mu.RLock()
+	listeners := slices.Clone(s.listeners)
+	s.mu.RUnlock()
+
+	var errs []error
+	commits := make([]Commit, 0, len(listeners))
+	for _, l := range listeners {
+		commit, err := l(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		commits = append(commits, commit)
+	}
+
+	if len(errs) > 0 {
+		err := fmt.Errorf("%w: %w", ErrNotApplied, errors.Join(errs...))
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+
+		s.log.Error("configuration not applied, keeping the active one", "error", err)
+		return err
+	}
+
+	for _, commit := range commits {
+		commit()
+	}
+
+	s.mu.Lock()
+	s.current = cfg
+	s.lastErr = nil
+	s.mu.Unlock()
+
+	s.log.Info("configuration applied", "listeners", len(listeners))
+	return nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testValidConfig = `{
+  "imagePullSecretName": "ipsn",
+  "imagePullSecretNamespace": "ipsns",
+  "secretSyncInterval": "1m",
+  "overrides": { "rn": "orn" }
+}`
+	testInvalidConfig = `{ "imagePullSecretName": "ipsn" }`
+)
+
+func TestStore_Load(t *testing.T) {
+	initial := &apiv1.Config{ImagePullSecretName: "initial"}
+
+	tcs := []struct {
+		name            string
+		data            string
+		expectedErr     bool
+		expectedSecret  string
+		expectedUpdates int
+	}{
+		{
+			name:            "valid configuration is applied",
+			data:            testValidConfig,
+			expectedSecret:  "ipsn",
+			expectedUpdates: 1,
+		},
+		{
+			name:            "invalid configuration is rejected",
+			data:            testInvalidConfig,
+			expectedErr:     true,
+			expectedSecret:  "initial",
+			expectedUpdates: 0,
+		},
+		{
+			name:            "malformed configuration is rejected",
+			data:            `{`,
+			expectedErr:     true,
+			expectedSecret:  "initial",
+			expectedUpdates: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewStore(initial)
+
+			var updates int
+			store.Subscribe(func(*apiv1.Config) (Commit, error) {
+				return func() { updates++ }, nil
+			})
+
+			err := store.Load(strings.NewReader(tc.data))
+			assert.Equal(t, tc.expectedErr, err != nil)
+			assert.Equal(t, tc.expectedErr, store.LastError() != nil)
+			assert.Equal(t, tc.expectedSecret, store.Get().ImagePullSecretName)
+			assert.Equal(t, tc.expectedUpdates, updates)
+		})
+	}
+}
+
+func TestStore_Set_listenerError(t *testing.T) {
+	store := NewStore(&apiv1.Config{ImagePullSecretName: "initial"})
+
+	var updates int
+	var prepared int
+	store.Subscribe(func(*apiv1.Config) (Commit, error) {
+		prepared++
+		return func() { updates++ }, nil
+	})
+	store.Subscribe(func(*apiv1.Config) (Commit, error) {
+		return nil, errors.New("test error")
+	})
+
+	err := store.Load(strings.NewReader(testValidConfig))
+	require.ErrorIs(t, err, ErrNotApplied)
+	assert.ErrorContains(t, err, "test error")
+	assert.ErrorIs(t, store.LastError(), ErrNotApplied)
+
+	// the listeners that prepared the switch are not committed
+	assert.Equal(t, 1, prepared)
+	assert.Equal(t, 0, updates)
+	assert.Equal(t, "initial", store.Get().ImagePullSecretName)
+
+}
+
+func TestFileWatcher_sync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(testValidConfig), 0o600))
+
+	store := NewStore(&apiv1.Config{ImagePullSecretName: "initial"})
+
+	var updates int
+	store.Subscribe(func(*apiv1.Config) (Commit, error) {
+		return func() { updates++ }, nil
+	})
+
+	w := FileWatcher{Path: path, Store: store}
+
+	w.sync(slog.Default())
+	assert.Equal(t, 1, updates)
+	assert.Equal(t, "ipsn", store.Get().ImagePullSecretName)
+
+	// unchanged content must not trigger another update
+	w.sync(slog.Default())
+	assert.Equal(t, 1, updates)
+
+	// invalid content keeps the active configuration
+	require.NoError(t, os.WriteFile(path, []byte(testInvalidConfig), 0o600))
+	w.sync(slog.Default())
+	assert.Equal(t, 1, updates)
+	assert.Equal(t, "ipsn", store.Get().ImagePullSecretName)
+	assert.Error(t, store.LastError())
+}
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
+	"github.com/kyma-project/rt-bootstrapper/internal/config"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// notAppliedRetryInterval - the delay of the next attempt to apply a valid
+// configuration the listeners could not apply
+const notAppliedRetryInterval = 30 * time.Second
+
+// BootstrapperConfigReconciler loads the spec of the BootstrapperConfig into
+// the configuration store of every replica; the leader reports the outcome of
+// its own load in the status
+type BootstrapperConfigReconciler struct {
+	client.Client
+	// Name of the BootstrapperConfig that contains the configuration
+	Name  string
+	Store *config.Store
+
+	// mu guards loaded
+	mu     sync.Mutex
+	loaded *loadResult
+	// statusSync - triggers the status update after every load
+	statusSync chan event.GenericEvent
+}
+
+// loadResult - the outcome of loading a generation of the spec
+type loadResult struct {
+	generation int64
+	err        error
+	// active - a configuration is active after the load
+	active bool
+}
+
+// +kubebuilder:rbac:groups=rt-bootstrapper.kyma-project.io,resources=bootstrapperconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rt-bootstrapper.kyma-project.io,resources=bootstrapperconfigs/status,verbs=get;update;patch
+
+func (r *BootstrapperConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := slog.Default().With(logID, "bootstrapper-config-reconcile", "req", req)
+
+	var bc rtbootstrapperv1alpha1.BootstrapperConfig
+	if err := r.Get(ctx, req.NamespacedName, &bc); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Warn("bootstrapper config not found, keeping the active configuration")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	log = log.With("generation", bc.Generation)
+
+	// the spec goes through the same decoding and validation as the
+	// configuration document
+	loadErr := r.load(bc.Spec.Config)
+
+	r.mu.Lock()
+	r.loaded = &loadResult{
+		generation: bc.Generation,
+		err:        loadErr,
+		active:     r.Store.Get() != nil,
+	}
+	r.mu.Unlock()
+
+	select {
+	case r.statusSync <- event.GenericEvent{Object: &bc}:
+	default:
+		// the status update is already pending
+	}
+
+	// the generation does not change, so the valid configuration is applied
+	// again until the listeners accept it
+	if errors.Is(loadErr, config.ErrNotApplied) {
+		log.Warn("configuration not applied, retrying", "retry-after", notAppliedRetryInterval)
+		return ctrl.Result{RequeueAfter: notAppliedRetryInterval}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileStatus - reports the outcome of the last load in the status; it
+// runs on the leader only, so the replicas don't overwrite the status of each
+// other
+func (r *BootstrapperConfigReconciler) reconcileStatus(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := slog.Default().With(logID, "bootstrapper-config-status", "req", req)
+
+	r.mu.Lock()
+	loaded := r.loaded
+	r.mu.Unlock()
+
+	if loaded == nil {
+		log.Debug("spec not loaded yet")
+		return ctrl.Result{}, nil
+	}
+
+	var bc rtbootstrapperv1alpha1.BootstrapperConfig
+	if err := r.Get(ctx, req.NamespacedName, &bc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	log = log.With("generation", bc.Generation)
+
+	// the load of the current generation triggers another status update
+	if bc.Generation != loaded.generation {
+		log.Debug("current generation not loaded yet", "loaded-generation", loaded.generation)
+		return ctrl.Result{}, nil
+	}
+
+	status := bc.Status.DeepCopy()
+	setStatus(status, loaded.generation, loaded.err, loaded.active)
+
+	if equality.Semantic.DeepEqual(status, &bc.Status) {
+		log.Debug("status unchanged")
+		return ctrl.Result{}, nil
+	}
+
+	bc.Status = *status
+	if err := r.Status().Update(ctx, &bc); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.Debug("status updated")
+	return ctrl.Result{}, nil
+}
+
+func (r *BootstrapperConfigReconciler) load(cfg apiv1.Config) error {
+	data, err := apiv1.MarshalConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return r.Store.Load(bytes.NewReader(data))
+}
+
+func setStatus(status *rtbootstrapperv1alpha1.BootstrapperConfigStatus, generation int64, err error, active bool) {
+	status.ObservedGeneration = generation
+
+	// the valid configuration some of the listeners could not apply
+	if errors.Is(err, config.ErrNotApplied) {
+		status.LastError = err.Error()
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               rtbootstrapperv1alpha1.ConditionTypeValid,
+			Status:             metav1.ConditionTrue,
+			Reason:             rtbootstrapperv1alpha1.ReasonConfigValid,
+			Message:            "configuration is valid",
+			ObservedGeneration: generation,
+		})
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               rtbootstrapperv1alpha1.ConditionTypeApplied,
+			Status:             metav1.ConditionFalse,
+			Reason:             rtbootstrapperv1alpha1.ReasonConfigNotApplied,
+			Message:            err.Error(),
+			ObservedGeneration: generation,
+		})
+		return
+	}
+
+	if err != nil {
+		status.LastError = err.Error()
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               rtbootstrapperv1alpha1.ConditionTypeValid,
+			Status:             metav1.ConditionFalse,
+			Reason:             rtbootstrapperv1alpha1.ReasonConfigInvalid,
+			Message:            err.Error(),
+			ObservedGeneration: generation,
+		})
+
+		applied := metav1.Condition{
+			Type:               rtbootstrapperv1alpha1.ConditionTypeApplied,
+			Status:             metav1.ConditionFalse,
+			Reason:             rtbootstrapperv1alpha1.ReasonPreviousConfigActive,
+			Message:            "the previously applied configuration is still active",
+			ObservedGeneration: generation,
+		}
+		// no valid configuration was read on startup
+		if !active {
+			applied.Reason = rtbootstrapperv1alpha1.ReasonNoConfigActive
+			applied.Message = "no configuration is active, the pods are not defaulted"
+		}
+		meta.SetStatusCondition(&status.Conditions, applied)
+		return
+	}
+
+	status.LastError = ""
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               rtbootstrapperv1alpha1.ConditionTypeValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             rtbootstrapperv1alpha1.ReasonConfigValid,
+		Message:            "configuration is valid",
+		ObservedGeneration: generation,
+	})
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               rtbootstrapperv1alpha1.ConditionTypeApplied,
+		Status:             metav1.ConditionTrue,
+		Reason:             rtbootstrapperv1alpha1.ReasonConfigApplied,
+		Message:            "configuration is active",
+		ObservedGeneration: generation,
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BootstrapperConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	slog.Debug("setting up with manager", "bootstrapper-config-name", r.Name)
+
+	isBootstrapperConfig := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.Name
+	})
+
+	// a single pending update covers all the loads, it reports the last one
+	r.statusSync = make(chan event.GenericEvent, 1)
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&rtbootstrapperv1alpha1.BootstrapperConfig{}, builder.WithPredicates(
+			isBootstrapperConfig,
+			predicate.GenerationChangedPredicate{})).
+		WithOptions(controller.Options{
+			NeedLeaderElection: ptr.To(false),
+		}).
+		Named("bootstrapper-config").
+		Complete(r)
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		WatchesRawSource(source.Channel(r.statusSync, &handler.EnqueueRequestForObject{})).
+		Named("bootstrapper-config-status").
+		Complete(reconcile.Func(r.reconcileStatus))
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
+	"github.com/kyma-project/rt-bootstrapper/internal/config"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_BootstrapperConfigReconciler_Reconcile(t *testing.T) {
+	const name = "default"
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, rtbootstrapperv1alpha1.AddToScheme(scheme))
+
+	validConfig := apiv1.Config{
+		Overrides:                map[string]string{"rn": "orn"},
+		ImagePullSecretName:      "ipsn",
+		ImagePullSecretNamespace: "ipsns",
+		SecretSyncInterval:       apiv1.Duration(time.Minute),
+	}
+
+	tcs := []struct {
+		name              string
+		spec              apiv1.Config
+		listenerErr       error
+		expectedSecret    string
+		expectedValid     metav1.ConditionStatus
+		expectedApplied   metav1.ConditionStatus
+		expectedLastError string
+		expectedRequeue   bool
+	}{
+		{
+			name:            "valid configuration",
+			spec:            validConfig,
+			expectedSecret:  "ipsn",
+			expectedValid:   metav1.ConditionTrue,
+			expectedApplied: metav1.ConditionTrue,
+		},
+		{
+			name:              "configuration not applied",
+			spec:              validConfig,
+			listenerErr:       errors.New("unable to build pod defaulters"),
+			expectedSecret:    "initial",
+			expectedValid:     metav1.ConditionTrue,
+			expectedApplied:   metav1.ConditionFalse,
+			expectedLastError: "unable to build pod defaulters",
+			expectedRequeue:   true,
+		},
+		{
+			name: "invalid configuration",
+			spec: apiv1.Config{
+				Overrides:                map[string]string{"rn": "orn"},
+				ImagePullSecretName:      "ipsn",
+				ImagePullSecretNamespace: "Invalid_Namespace",
+				SecretSyncInterval:       apiv1.Duration(time.Minute),
+			},
+			expectedSecret:    "initial",
+			expectedValid:     metav1.ConditionFalse,
+			expectedApplied:   metav1.ConditionFalse,
+			expectedLastError: "imagePullSecretNamespace: Invalid value",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			bc := &rtbootstrapperv1alpha1.BootstrapperConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       name,
+					Generation: 3,
+				},
+				Spec: rtbootstrapperv1alpha1.BootstrapperConfigSpec{Config: tc.spec},
+			}
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(bc).
+				WithStatusSubresource(bc).
+				Build()
+
+			store := config.NewStore(&apiv1.Config{ImagePullSecretName: "initial"})
+			store.Subscribe(func(*apiv1.Config) (config.Commit, error) {
+				if tc.listenerErr != nil {
+					return nil, tc.listenerErr
+				}
+				return func() {}, nil
+			})
+
+			r := BootstrapperConfigReconciler{
+				Client: c,
+				Name:   name,
+				Store:  store,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+
+			result, err := r.Reconcile(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRequeue, result.RequeueAfter > 0)
+
+			_, err = r.reconcileStatus(context.Background(), req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedSecret, store.Get().ImagePullSecretName)
+
+			var actual rtbootstrapperv1alpha1.BootstrapperConfig
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: name}, &actual))
+
+			assert.Equal(t, int64(3), actual.Status.ObservedGeneration)
+			assert.True(t, meta.IsStatusConditionPresentAndEqual(actual.Status.Conditions,
+				rtbootstrapperv1alpha1.ConditionTypeValid, tc.expectedValid))
+			assert.True(t, meta.IsStatusConditionPresentAndEqual(actual.Status.Conditions,
+				rtbootstrapperv1alpha1.ConditionTypeApplied, tc.expectedApplied))
+
+			if tc.expectedLastError == "" {
+				assert.Empty(t, actual.Status.LastError)
+				return
+			}
+			assert.Contains(t, actual.Status.LastError, tc.expectedLastError)
+		})
+	}
+}
+
+func Test_BootstrapperConfigReconciler_Reconcile_invalidOnStartup(t *testing.T) {
+	const name = "default"
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, rtbootstrapperv1alpha1.AddToScheme(scheme))
+
+	bc := &rtbootstrapperv1alpha1.BootstrapperConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Generation: 1,
+		},
+		Spec: rtbootstrapperv1alpha1.BootstrapperConfigSpec{Config: apiv1.Config{
+			ImagePullSecretName:      "ipsn",
+			ImagePullSecretNamespace: "Invalid_Namespace",
+		}},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(bc).
+		WithStatusSubresource(bc).
+		Build()
+
+	// the manager starts without a configuration if the bootstrapper config
+	// can not be read
+	store := config.NewStore(nil)
+
+	r := BootstrapperConfigReconciler{
+		Client: c,
+		Name:   name,
+		Store:  store,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Nil(t, store.Get())
+
+	_, err = r.reconcileStatus(context.Background(), req)
+	require.NoError(t, err)
+
+	var actual rtbootstrapperv1alpha1.BootstrapperConfig
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: name}, &actual))
+
+	assert.Equal(t, int64(1), actual.Status.ObservedGeneration)
+	assert.Contains(t, actual.Status.LastError, "imagePullSecretNamespace: Invalid value")
+	assert.True(t, meta.IsStatusConditionFalse(actual.Status.Conditions, rtbootstrapperv1alpha1.ConditionTypeValid))
+
+	applied := meta.FindStatusCondition(actual.Status.Conditions, rtbootstrapperv1alpha1.ConditionTypeApplied)
+	require.NotNil(t, applied)
+	assert.Equal(t, metav1.ConditionFalse, applied.Status)
+	assert.Equal(t, rtbootstrapperv1alpha1.ReasonNoConfigActive, applied.Reason)
+}
+
+func Test_BootstrapperConfigReconciler_reconcileStatus_notLoaded(t *testing.T) {
+	const name = "default"
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, rtbootstrapperv1alpha1.AddToScheme(scheme))
+
+	bc := &rtbootstrapperv1alpha1.BootstrapperConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Generation: 2,
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(bc).
+		WithStatusSubresource(bc).
+		Build()
+
+	r := BootstrapperConfigReconciler{
+		Client: c,
+		Name:   name,
+		Store:  config.NewStore(nil),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+
+	// nothing is reported before the spec is loaded
+	_, err := r.reconcileStatus(context.Background(), req)
+	require.NoError(t, err)
+
+	// the outcome of the previous generation is not reported for the current one
+	r.loaded = &loadResult{generation: 1}
+	_, err = r.reconcileStatus(context.Background(), req)
+	require.NoError(t, err)
+
+	var actual rtbootstrapperv1alpha1.BootstrapperConfig
+	require.NoError(t, c.Get(context.Background(), req.NamespacedName, &actual))
+	assert.Equal(t, rtbootstrapperv1alpha1.BootstrapperConfigStatus{}, actual.Status)
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ConfigMapReconciler loads the configuration stored in the ConfigMap into the
+// configuration store every time the ConfigMap changes
+type ConfigMapReconciler struct {
+	client.Client
+	types.NamespacedName
+	// Key of the ConfigMap entry that contains the configuration
+	Key   string
+	Store *config.Store
+	// InitialContent - the content the active configuration was loaded from
+	// on startup, so the first reconciliation does not reload it
+	InitialContent []byte
+
+	mu          sync.Mutex
+	lastContent []byte
+}
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := slog.Default().With(logID, "config-map-reconcile", "req", req)
+
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, req.NamespacedName, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Warn("configuration config map not found, keeping the active configuration")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	data, err := ConfigMapData(&cm, r.Key)
+	if err != nil {
+		log.Error("unable to read configuration, keeping the active one", "error", err)
+		return ctrl.Result{}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastContent == nil {
+		r.lastContent = r.InitialContent
+	}
+
+	if bytes.Equal(data, r.lastContent) {
+		log.Debug("configuration unchanged")
+		return ctrl.Result{}, nil
+	}
+	// remember the content even if it is invalid to report the error only once
+	r.lastContent = data
+
+	log.Info("configuration config map changed, reloading")
+	// the store reports and keeps track of invalid configurations; retrying
+	// the same content would not change the result
+	_ = r.Store.Load(bytes.NewReader(data))
+	return ctrl.Result{}, nil
+}
+
+// ConfigMapData - returns the configuration stored under the given key
+func ConfigMapData(cm *corev1.ConfigMap, key string) ([]byte, error) {
+	if data, found := cm.Data[key]; found {
+		return []byte(data), nil
+	}
+
+	if data, found := cm.BinaryData[key]; found {
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("key '%s' not found in config map %s/%s", key, cm.Namespace, cm.Name)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	slog.Debug("setting up with manager",
+		"config-map-name", r.Name,
+		"config-map-namespace", r.Namespace,
+		"config-map-key", r.Key)
+
+	isConfigMap := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.Name && obj.GetNamespace() == r.Namespace
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.ConfigMap{}, builder.WithPredicates(
+			isConfigMap,
+			predicate.ResourceVersionChangedPredicate{})).
+		// every replica serves admission requests, so every replica has to
+		// observe the configuration changes
+		WithOptions(controller.Options{
+			NeedLeaderElection: ptr.To(false),
+		}).
+		Named("config-map").
+		Complete(r)
+}
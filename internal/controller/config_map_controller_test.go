@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/config"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_ConfigMapReconciler_Reconcile(t *testing.T) {
+	const (
+		testKey    = "config.json"
+		testConfig = `{
+  "imagePullSecretName": "ipsn",
+  "imagePullSecretNamespace": "ipsns",
+  "secretSyncInterval": "1m",
+  "overrides": { "rn": "orn" }
+}`
+	)
+
+	nn := types.NamespacedName{Name: "test-config", Namespace: "test-ns"}
+
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nn.Name,
+				Namespace: nn.Namespace,
+			},
+			Data: data,
+		}
+	}
+
+	tcs := []struct {
+		name            string
+		cm              *corev1.ConfigMap
+		initialContent  string
+		expectedSecret  string
+		expectedUpdates int
+	}{
+		{
+			name:            "valid configuration",
+			cm:              newConfigMap(map[string]string{testKey: testConfig}),
+			expectedSecret:  "ipsn",
+			expectedUpdates: 1,
+		},
+		{
+			name:            "configuration loaded on startup",
+			cm:              newConfigMap(map[string]string{testKey: testConfig}),
+			initialContent:  testConfig,
+			expectedSecret:  "initial",
+			expectedUpdates: 0,
+		},
+		{
+			name:            "invalid configuration",
+			cm:              newConfigMap(map[string]string{testKey: "{}"}),
+			expectedSecret:  "initial",
+			expectedUpdates: 0,
+		},
+		{
+			name:            "missing key",
+			cm:              newConfigMap(map[string]string{"other": testConfig}),
+			expectedSecret:  "initial",
+			expectedUpdates: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			store := config.NewStore(&apiv1.Config{ImagePullSecretName: "initial"})
+
+			var updates int
+			store.Subscribe(func(*apiv1.Config) (config.Commit, error) {
+				return func() { updates++ }, nil
+			})
+
+			r := ConfigMapReconciler{
+				Client:         fake.NewClientBuilder().WithObjects(tc.cm).Build(),
+				NamespacedName: nn,
+				Key:            testKey,
+				Store:          store,
+				InitialContent: []byte(tc.initialContent),
+			}
+
+			req := ctrl.Request{NamespacedName: nn}
+			for range 2 {
+				_, err := r.Reconcile(context.Background(), req)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedSecret, store.Get().ImagePullSecretName)
+			assert.Equal(t, tc.expectedUpdates, updates)
+		})
+	}
+}
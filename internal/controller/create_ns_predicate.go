@@ -11,26 +11,15 @@ import (
 var _ predicate.TypedPredicate[client.Object] = &createNsPredicate{}
 
 type createNsPredicate struct {
-	log                      *slog.Logger
-	masterSecretNamspaceName string
+	log *slog.Logger
 }
 
-func (p createNsPredicate) isMasterSecretNamespace(namespaceName string) bool {
-	return p.masterSecretNamspaceName == namespaceName
-}
-
-// Create - handles the case of namespace creation (omits events comming from
-// the master secret namespace)
+// Create - handles the case of namespace creation; the master secret
+// namespaces are accepted too, the reconciliation omits only the master
+// secrets living in the created namespace
 func (p createNsPredicate) Create(e event.TypedCreateEvent[client.Object]) bool {
-	accept := !p.isMasterSecretNamespace(e.Object.GetName())
-
-	args := []any{
-		"accept", accept,
-		"name", e.Object.GetName(),
-	}
-
-	p.log.Debug("incomming create ns event", args...)
-	return accept
+	p.log.Debug("incomming create ns event", "name", e.Object.GetName())
+	return true
 }
 
 // Delete - omit event
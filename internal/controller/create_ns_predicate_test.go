@@ -9,62 +9,48 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 func Test_createNsPredicate(t *testing.T) {
-	type predicateResult = predicate.TypedPredicate[client.Object]
+	p := createNsPredicate{log: slog.Default()}
 
-	const masterSecretNamespace = "master-secret-namespace"
-
-	var (
-		masterNsCreateEvent = event.TypedCreateEvent[client.Object]{
-			Object: &corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: masterSecretNamespace,
-				},
-			},
-		}
-		testNsCreateEvent = event.TypedCreateEvent[client.Object]{
-			Object: &corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "test-namespace",
-				},
+	newNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
 			},
 		}
-
-		newTestCreateNsPredicate = func() predicateResult {
-			return &createNsPredicate{
-				log:                      slog.Default(),
-				masterSecretNamspaceName: masterSecretNamespace,
-			}
-		}
-	)
+	}
 
 	tcs := []struct {
-		name      string
-		predicate predicate.TypedPredicate[client.Object]
-		e         event.TypedCreateEvent[client.Object]
-		expected  bool
+		name     string
+		ns       *corev1.Namespace
+		expected bool
 	}{
 		{
-			name:      "is master namespace",
-			predicate: newTestCreateNsPredicate(),
-			e:         masterNsCreateEvent,
-			expected:  false,
+			// the other master secrets have to be replicated into it
+			name:     "is master namespace",
+			ns:       newNamespace("master-secret-namespace"),
+			expected: true,
 		},
 		{
-			name:      "is not master namespace",
-			predicate: newTestCreateNsPredicate(),
-			e:         testNsCreateEvent,
-			expected:  true,
+			name:     "is not master namespace",
+			ns:       newNamespace("test-namespace"),
+			expected: true,
 		},
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := tc.predicate.Create(tc.e)
+			actual := p.Create(event.TypedCreateEvent[client.Object]{Object: tc.ns})
 			assert.Equal(t, tc.expected, actual)
 		})
 	}
+
+	t.Run("other events are omitted", func(t *testing.T) {
+		ns := newNamespace("test-namespace")
+		assert.False(t, p.Update(event.TypedUpdateEvent[client.Object]{ObjectOld: ns, ObjectNew: ns}))
+		assert.False(t, p.Delete(event.TypedDeleteEvent[client.Object]{Object: ns}))
+		assert.False(t, p.Generic(event.TypedGenericEvent[client.Object]{Object: ns}))
+	})
 }
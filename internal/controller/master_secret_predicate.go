@@ -3,6 +3,7 @@ package controller
 import (
 	"bytes"
 	"log/slog"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -11,11 +12,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
-var _ predicate.TypedPredicate[client.Object] = &masterSecret{}
+var (
+	_ predicate.TypedPredicate[client.Object] = &masterSecret{}
+	_ predicate.TypedPredicate[client.Object] = &masterSecretCredentials{}
+)
 
 type masterSecret struct {
-	log *slog.Logger
-	types.NamespacedName
+	log     *slog.Logger
+	targets func() []types.NamespacedName
+}
+
+func (p masterSecret) isTargetName(name string) bool {
+	return slices.ContainsFunc(p.targets(), func(target types.NamespacedName) bool {
+		return target.Name == name
+	})
 }
 
 // Create - handles the case of master secret creation
@@ -29,7 +39,10 @@ func (p masterSecret) Create(e event.TypedCreateEvent[client.Object]) bool {
 		"secret-namespace", secretNamespace,
 	}
 
-	accept := secretName == p.Name && secretNamespace == p.Namespace
+	accept := slices.Contains(p.targets(), types.NamespacedName{
+		Name:      secretName,
+		Namespace: secretNamespace,
+	})
 	p.log.With(args...).Debug("incomming create secret event", "accept", accept)
 
 	return accept
@@ -41,7 +54,7 @@ func (p masterSecret) Delete(e event.TypedDeleteEvent[client.Object]) bool {
 }
 
 // Update - handles the case of an update when a secret has the same name
-// as the master secret and '.dockerconfigjson' entry changed
+// as one of the master secrets and '.dockerconfigjson' entry changed
 func (p masterSecret) Update(e event.TypedUpdateEvent[client.Object]) bool {
 
 	secretNew := e.ObjectNew.(*corev1.Secret)
@@ -55,7 +68,7 @@ func (p masterSecret) Update(e event.TypedUpdateEvent[client.Object]) bool {
 		"secret-namespace", secretNew.Namespace,
 	}
 
-	idMatch := p.Name == secretNew.Name
+	idMatch := p.isTargetName(secretNew.Name)
 	accept := idMatch && !bytes.Equal(valNew, valOld)
 
 	p.log.With(args...).Debug("incomming update secret event", "accept", accept)
@@ -66,3 +79,28 @@ func (p masterSecret) Update(e event.TypedUpdateEvent[client.Object]) bool {
 func (p masterSecret) Generic(e event.TypedGenericEvent[client.Object]) bool {
 	return false
 }
+
+// masterSecretCredentials - the master secret events the credentials store is
+// refreshed by; unlike the replication, it has to observe the deletions too
+type masterSecretCredentials struct {
+	masterSecret
+}
+
+// Delete - handles the case of master secret deletion
+func (p masterSecretCredentials) Delete(e event.TypedDeleteEvent[client.Object]) bool {
+	secretName := e.Object.GetName()
+	secretNamespace := e.Object.GetNamespace()
+
+	args := []any{
+		"secret-name", secretName,
+		"secret-namespace", secretNamespace,
+	}
+
+	accept := slices.Contains(p.targets(), types.NamespacedName{
+		Name:      secretName,
+		Namespace: secretNamespace,
+	})
+	p.log.With(args...).Debug("incomming delete secret event", "accept", accept)
+
+	return accept
+}
@@ -37,9 +37,11 @@ func Test_masterSecret_Create(t *testing.T) {
 		newTestMasterSecretPredicate = func() predicateResult {
 			return &masterSecret{
 				log: slog.Default(),
-				NamespacedName: types.NamespacedName{
-					Name:      masterSecretName,
-					Namespace: masterSecretNamespace,
+				targets: func() []types.NamespacedName {
+					return []types.NamespacedName{{
+						Name:      masterSecretName,
+						Namespace: masterSecretNamespace,
+					}}
 				},
 			}
 		}
@@ -85,3 +87,32 @@ func Test_masterSecret_Create(t *testing.T) {
 	}
 
 }
+
+func Test_masterSecretCredentials_Delete(t *testing.T) {
+	target := types.NamespacedName{Name: "master-secret", Namespace: "master-secret-namespace"}
+
+	p := masterSecretCredentials{
+		masterSecret: masterSecret{
+			log: slog.Default(),
+			targets: func() []types.NamespacedName {
+				return []types.NamespacedName{target}
+			},
+		},
+	}
+
+	newTypedDeleteEvent := func(name, namespace string) event.TypedDeleteEvent[client.Object] {
+		return event.TypedDeleteEvent[client.Object]{
+			Object: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+		}
+	}
+
+	assert.True(t, p.Delete(newTypedDeleteEvent(target.Name, target.Namespace)))
+	// the deleted replicas do not change the credentials
+	assert.False(t, p.Delete(newTypedDeleteEvent(target.Name, "test-namespace")))
+	assert.False(t, p.Delete(newTypedDeleteEvent("test", target.Namespace)))
+}
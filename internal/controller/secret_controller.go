@@ -20,12 +20,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
 	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -33,15 +37,86 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // SecretReconciler reconciles a Secret object
 type SecretReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
-	types.NamespacedName
+	// Targets - the master secrets replicated to all namespaces
+	Targets            []types.NamespacedName
 	SecretSyncInterval time.Duration
+	// Credentials - the registries of the master secrets, optional
+	Credentials *credentials.Store
+
+	// mu guards Targets and SecretSyncInterval which can be replaced at
+	// runtime by SetTargets
+	mu                sync.RWMutex
+	resync            chan event.GenericEvent
+	credentialsResync chan event.GenericEvent
+}
+
+const resyncBufferSize = 8
+
+// SetTargets - replaces the master secrets and the synchronization interval;
+// the new master secrets are synchronized right away
+func (r *SecretReconciler) SetTargets(targets []types.NamespacedName, syncInterval time.Duration) {
+	r.mu.Lock()
+	prev := r.Targets
+	r.Targets = targets
+	r.SecretSyncInterval = syncInterval
+	r.mu.Unlock()
+
+	slog.Info("master secret targets updated",
+		"prev", prev,
+		"new", targets,
+		"secret-sync-interval", syncInterval)
+
+	if r.Credentials != nil {
+		names := make([]string, 0, len(targets))
+		for _, target := range targets {
+			names = append(names, target.Name)
+		}
+		r.Credentials.Retain(names)
+	}
+
+	notifyTargets(r.resync, targets)
+	notifyTargets(r.credentialsResync, targets)
+}
+
+func notifyTargets(resync chan event.GenericEvent, targets []types.NamespacedName) {
+	if resync == nil {
+		return
+	}
+
+	for _, target := range targets {
+		e := event.GenericEvent{
+			Object: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      target.Name,
+					Namespace: target.Namespace,
+				},
+			},
+		}
+
+		select {
+		case resync <- e:
+		default:
+			slog.Warn("resync queue full, master secret will be synchronized on next event",
+				"target", target)
+		}
+	}
+}
+
+func (r *SecretReconciler) targets() ([]types.NamespacedName, time.Duration) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Targets, r.SecretSyncInterval
 }
 
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;patch
@@ -51,11 +126,23 @@ const (
 )
 
 func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	targets, secretSyncInterval := r.targets()
 
-	isMasterSecretUpdated := req.Namespace == r.Namespace && req.Name == r.Name
-	isCredentialsSecretUpdated := (req.Namespace != "" && req.Namespace != r.Namespace) && req.Name == r.Name
 	isNamespaceCreated := req.Namespace == ""
 
+	// the replicas have the same name as their master secret
+	index := slices.IndexFunc(targets, func(target types.NamespacedName) bool {
+		return target.Name == req.Name
+	})
+
+	var target types.NamespacedName
+	if index != -1 {
+		target = targets[index]
+	}
+
+	isMasterSecretUpdated := index != -1 && req.Namespace == target.Namespace
+	isCredentialsSecretUpdated := index != -1 && !isNamespaceCreated && req.Namespace != target.Namespace
+
 	log := slog.Default().With(
 		logID, "reconcile",
 		"req", req,
@@ -65,16 +152,43 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		"uuid", uuid.NewString(),
 	)
 
-	log.Debug("fetching master-secret", "namespaced-name", r.NamespacedName)
+	if isNamespaceCreated {
+		log.Debug("attempting to create secrets")
 
-	var masterSecret corev1.Secret
-	if err := r.Get(ctx, r.NamespacedName, &masterSecret); err != nil {
-		return ctrl.Result{}, err
+		errors := []string{}
+		for _, target := range targets {
+			// the master secret is not replicated into its own namespace
+			if target.Namespace == req.Name {
+				continue
+			}
+
+			data, err := r.masterSecretData(ctx, log, target)
+			if err != nil {
+				errors = append(errors, err.Error())
+				continue
+			}
+
+			if err := r.syncSecret(ctx, log, target.Name, req.Name, data, false); err != nil {
+				errors = append(errors, err.Error())
+			}
+		}
+
+		if len(errors) > 0 {
+			msg := strings.Join(errors, ",")
+			return ctrl.Result{}, fmt.Errorf(
+				"failed to reconcile due to patch errors: %s", msg)
+		}
+		return ctrl.Result{}, nil
 	}
 
 	if isMasterSecretUpdated {
 		log.Debug("attempting to synchronize secrets")
 
+		data, err := r.masterSecretData(ctx, log, target)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
 		var namespaceList corev1.NamespaceList
 		if err := r.List(ctx, &namespaceList); err != nil {
 			return ctrl.Result{}, err
@@ -83,25 +197,13 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		errors := []string{}
 		for _, namespace := range namespaceList.Items {
 			// omit master-secret
-			if namespace.Namespace == r.Name {
+			if namespace.Name == target.Namespace {
 				continue
 			}
 
-			credentialsSecret := createCredentialSecret(r.Name, namespace.Name,
-				masterSecret.Data[corev1.DockerConfigJsonKey])
-
-			err := r.Patch(ctx, credentialsSecret, client.Apply, &client.PatchOptions{
-				FieldManager: apiv1.FiledManager,
-			})
-
-			if err != nil {
+			if err := r.syncSecret(ctx, log, target.Name, namespace.Name, data, false); err != nil {
 				errors = append(errors, err.Error())
-				continue
 			}
-
-			log.WithGroup("secret").Debug("secret patched successfully",
-				"name", credentialsSecret.Name,
-				"namespace", credentialsSecret.Namespace)
 		}
 
 		if len(errors) > 0 {
@@ -110,53 +212,139 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 				"failed to reconcile due to patch errors: %s", msg)
 		}
 
-		return ctrl.Result{RequeueAfter: r.SecretSyncInterval}, nil
+		return ctrl.Result{RequeueAfter: secretSyncInterval}, nil
 	}
 
 	if isCredentialsSecretUpdated {
 		log.Debug("attempting to synchroinize secret")
 
-		credentialsSecret := createCredentialSecret(r.Name, req.Namespace,
-			masterSecret.Data[corev1.DockerConfigJsonKey])
-
-		return ctrl.Result{}, r.Patch(ctx, credentialsSecret, client.Apply, &client.PatchOptions{
-			FieldManager: apiv1.FiledManager,
-			Force:        ptr.To(true),
-		})
+		data, err := r.masterSecretData(ctx, log, target)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.syncSecret(ctx, log, target.Name, req.Namespace, data, true)
 	}
 
-	if isNamespaceCreated {
-		log.Debug("attempting to create secret")
+	log.Warn("unhandled request")
+	return ctrl.Result{}, nil
+}
+
+// reconcileCredentials - refreshes the registries the master secret holds
+// credentials for
+func (r *SecretReconciler) reconcileCredentials(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	targets, _ := r.targets()
+	if !slices.Contains(targets, req.NamespacedName) {
+		return ctrl.Result{}, nil
+	}
 
-		credentialsSecret := createCredentialSecret(r.Name, req.Name,
-			masterSecret.Data[corev1.DockerConfigJsonKey])
+	log := slog.Default().With(logID, "reconcile-credentials", "req", req)
 
-		return ctrl.Result{}, r.Patch(ctx, credentialsSecret, client.Apply, &client.PatchOptions{
-			FieldManager: apiv1.FiledManager,
-		})
+	var masterSecret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &masterSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Warn("master secret not found, it serves no registry")
+			r.Credentials.Delete(req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
 	}
 
-	log.Warn("unhandled request")
+	// retrying the same content would not change the result
+	if err := r.Credentials.Set(req.Name, masterSecret.Data[corev1.DockerConfigJsonKey]); err != nil {
+		log.Error("unable to read master secret credentials", "error", err)
+	}
 	return ctrl.Result{}, nil
 }
 
+func (r *SecretReconciler) masterSecretData(
+	ctx context.Context,
+	log *slog.Logger,
+	target types.NamespacedName) ([]byte, error) {
+
+	log.Debug("fetching master-secret", "namespaced-name", target)
+
+	var masterSecret corev1.Secret
+	if err := r.Get(ctx, target, &masterSecret); err != nil {
+		return nil, err
+	}
+	return masterSecret.Data[corev1.DockerConfigJsonKey], nil
+}
+
+// syncSecret - replicates the master secret data into the namespace
+func (r *SecretReconciler) syncSecret(
+	ctx context.Context,
+	log *slog.Logger,
+	name, namespace string,
+	data []byte,
+	force bool) error {
+
+	credentialsSecret := createCredentialSecret(name, namespace, data)
+
+	opts := client.PatchOptions{
+		FieldManager: apiv1.FiledManager,
+	}
+	if force {
+		opts.Force = ptr.To(true)
+	}
+
+	if err := r.Patch(ctx, credentialsSecret, client.Apply, &opts); err != nil {
+		return err
+	}
+
+	log.WithGroup("secret").Debug("secret patched successfully",
+		"name", credentialsSecret.Name,
+		"namespace", credentialsSecret.Namespace)
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	slog.Debug("setting up with manager",
-		"master-secert-name", r.Name,
-		"master-secret-namespace", r.Namespace)
+	targets, _ := r.targets()
+	slog.Debug("setting up with manager", "master-secrets", targets)
+
+	getTargets := func() []types.NamespacedName {
+		targets, _ := r.targets()
+		return targets
+	}
 
 	p1 := &createNsPredicate{
-		log:                      slog.Default().With(logID, "namespace-predicate"),
-		masterSecretNamspaceName: r.Namespace,
+		log: slog.Default().With(logID, "namespace-predicate"),
 	}
 
 	p2 := &masterSecret{
-		log:            slog.Default().With(logID, "master-secret-predicate"),
-		NamespacedName: r.NamespacedName,
+		log:     slog.Default().With(logID, "master-secret-predicate"),
+		targets: getTargets,
+	}
+
+	p3 := &masterSecretCredentials{
+		masterSecret: masterSecret{
+			log:     slog.Default().With(logID, "master-secret-credentials-predicate"),
+			targets: getTargets,
+		},
+	}
+
+	r.resync = make(chan event.GenericEvent, resyncBufferSize)
+
+	if r.Credentials != nil {
+		r.credentialsResync = make(chan event.GenericEvent, resyncBufferSize)
+
+		err := ctrl.NewControllerManagedBy(mgr).
+			WatchesRawSource(source.Channel(r.credentialsResync, &handler.EnqueueRequestForObject{})).
+			Watches(&corev1.Secret{},
+				&handler.EnqueueRequestForObject{},
+				builder.WithPredicates(p3)).
+			WithOptions(controller.Options{
+				NeedLeaderElection: ptr.To(false),
+			}).
+			Named("docker-credentials-registries").
+			Complete(reconcile.Func(r.reconcileCredentials))
+		if err != nil {
+			return err
+		}
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
+		WatchesRawSource(source.Channel(r.resync, &handler.EnqueueRequestForObject{})).
 		Watches(&corev1.Namespace{},
 			&handler.EnqueueRequestForObject{},
 			builder.WithPredicates(p1)).
@@ -17,7 +17,19 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("Secret Controller", func() {
@@ -30,3 +42,119 @@ var _ = Describe("Secret Controller", func() {
 		})
 	})
 })
+
+func Test_SecretReconciler_multipleTargets(t *testing.T) {
+	newMasterSecret := func(name, namespace, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(data)},
+		}
+	}
+
+	newNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		newNamespace("kyma-system"),
+		newNamespace("other-system"),
+		newNamespace("tenant"),
+		newMasterSecret("mirror-a", "kyma-system", "a"),
+		newMasterSecret("mirror-b", "other-system", "b"),
+	).Build()
+
+	r := SecretReconciler{
+		Client: c,
+		Targets: []types.NamespacedName{
+			{Name: "mirror-a", Namespace: "kyma-system"},
+			{Name: "mirror-b", Namespace: "other-system"},
+		},
+		SecretSyncInterval: time.Minute,
+	}
+
+	assertReplica := func(name, namespace, expected string) {
+		var secret corev1.Secret
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, &secret))
+		assert.Equal(t, expected, string(secret.Data[corev1.DockerConfigJsonKey]))
+	}
+
+	t.Run("master secret replicated to all other namespaces", func(t *testing.T) {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "mirror-b", Namespace: "other-system"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+
+		assertReplica("mirror-b", "kyma-system", "b")
+		assertReplica("mirror-b", "tenant", "b")
+	})
+
+	t.Run("all master secrets replicated to created namespace", func(t *testing.T) {
+		require.NoError(t, c.Create(context.Background(), newNamespace("created")))
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "created"},
+		})
+		require.NoError(t, err)
+
+		assertReplica("mirror-a", "created", "a")
+		assertReplica("mirror-b", "created", "b")
+	})
+
+	t.Run("other master secrets replicated to master secret namespace", func(t *testing.T) {
+		_, err := r.Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "other-system"},
+		})
+		require.NoError(t, err)
+
+		assertReplica("mirror-a", "other-system", "a")
+		// the master secret is kept as is
+		assertReplica("mirror-b", "other-system", "b")
+	})
+}
+
+func Test_SecretReconciler_reconcileCredentials(t *testing.T) {
+	target := types.NamespacedName{Name: "master", Namespace: "kyma-system"}
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: target.Namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths": {"mirror.local": {}}}`),
+		},
+	}).Build()
+
+	r := SecretReconciler{
+		Client:      c,
+		Targets:     []types.NamespacedName{target},
+		Credentials: credentials.NewStore(),
+	}
+
+	// replicas do not change the credentials
+	_, err := r.reconcileCredentials(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: target.Name, Namespace: "tenant"},
+	})
+	require.NoError(t, err)
+	_, found := r.Credentials.Registries(target.Name)
+	assert.False(t, found)
+
+	_, err = r.reconcileCredentials(context.Background(), ctrl.Request{NamespacedName: target})
+	require.NoError(t, err)
+	registries, found := r.Credentials.Registries(target.Name)
+	assert.True(t, found)
+	assert.Equal(t, []string{"mirror.local"}, registries)
+
+	// the deleted master secret serves no registry
+	require.NoError(t, c.Delete(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: target.Name, Namespace: target.Namespace},
+	}))
+	_, err = r.reconcileCredentials(context.Background(), ctrl.Request{NamespacedName: target})
+	require.NoError(t, err)
+	registries, found = r.Credentials.Registries(target.Name)
+	assert.True(t, found)
+	assert.Empty(t, registries)
+}
@@ -0,0 +1,103 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+)
+
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+type dockerConfigAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
+}
+
+// Auth - the credentials of a registry
+type Auth struct {
+	Username string
+	Password string
+	// IdentityToken - the refresh token exchanged for the registry tokens
+	IdentityToken string
+	// RegistryToken - the bearer token sent to the registry as is
+	RegistryToken string
+}
+
+// ParseDockerConfigAuths - returns the credentials found in the auths of the
+// '.dockerconfigjson' content by the registry host; the first key of the
+// same registry wins; the registries with unreadable credentials are kept
+// without the credentials
+func ParseDockerConfigAuths(data []byte) (map[string]Auth, error) {
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+
+	result := make(map[string]Auth, len(cfg.Auths))
+	for _, key := range slices.Sorted(maps.Keys(cfg.Auths)) {
+		registry := RegistryHost(key)
+		if _, found := result[registry]; registry == "" || found {
+			continue
+		}
+
+		auth, err := cfg.Auths[key].decode()
+		if err != nil {
+			slog.Warn("unable to read docker config auth, ignoring credentials",
+				"registry", registry,
+				"error", err)
+		}
+		result[registry] = auth
+	}
+
+	return result, nil
+}
+
+// decode - reads the credentials, the 'auth' field holds the base64 encoded
+// 'username:password' and takes precedence over the username and password
+func (a dockerConfigAuth) decode() (Auth, error) {
+	result := Auth{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+		RegistryToken: a.RegistryToken,
+	}
+
+	if a.Auth == "" {
+		return result, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+	if err != nil {
+		return Auth{}, err
+	}
+
+	username, password, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return Auth{}, errors.New("expected the 'username:password' format")
+	}
+
+	result.Username = username
+	result.Password = password
+	return result, nil
+}
+
+// RegistryHost - returns the registry host of the auths key; the keys can be
+// URLs, for example 'https://index.docker.io/v1/'
+func RegistryHost(key string) string {
+	host := strings.TrimPrefix(key, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host, _, _ = strings.Cut(host, "/")
+	return k8s.NormalizeRegistry(host)
+}
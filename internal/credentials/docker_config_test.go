@@ -0,0 +1,51 @@
+package credentials_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerConfigAuths_registries(t *testing.T) {
+	tcs := []struct {
+		name     string
+		data     string
+		expected []string
+		err      bool
+	}{
+		{
+			name: "registries of all auths",
+			data: `{"auths": {
+  "https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+  "mirror.local:5000": {"auth": "dXNlcjpwYXNz"},
+  "http://gcr.io/project": {"auth": "dXNlcjpwYXNz"}
+}}`,
+			expected: []string{"docker.io", "gcr.io", "mirror.local:5000"},
+		},
+		{
+			name: "no auths",
+			data: `{}`,
+		},
+		{
+			name: "invalid content",
+			data: `not json`,
+			err:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			auths, err := credentials.ParseDockerConfigAuths([]byte(tc.data))
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, slices.Sorted(maps.Keys(auths)))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package credentials
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Store - keeps the registries the master pull secrets hold credentials for
+// together with the credentials; the store is refreshed by the secret
+// controller and read by the webhook
+type Store struct {
+	mu    sync.RWMutex
+	auths map[string]map[string]Auth
+}
+
+func NewStore() *Store {
+	return &Store{
+		auths: map[string]map[string]Auth{},
+	}
+}
+
+// Set - replaces the registries of the secret with the ones found in its
+// '.dockerconfigjson'; the secret serves no registry if the content is invalid
+func (s *Store) Set(secretName string, dockerConfigJSON []byte) error {
+	auths, err := ParseDockerConfigAuths(dockerConfigJSON)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.auths[secretName] = map[string]Auth{}
+		return err
+	}
+
+	s.auths[secretName] = auths
+	slog.Debug("pull secret credentials updated",
+		"secret-name", secretName,
+		"registries", slices.Sorted(maps.Keys(auths)))
+
+	return nil
+}
+
+// Delete - forgets the registries of the deleted secret; the secret serves no
+// registry until it is set again
+func (s *Store) Delete(secretName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[secretName] = map[string]Auth{}
+}
+
+// Retain - forgets the registries of all the secrets not listed
+func (s *Store) Retain(secretNames []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.auths {
+		if slices.Contains(secretNames, name) {
+			continue
+		}
+		delete(s.auths, name)
+	}
+}
+
+// Registries - returns the registries the secret holds credentials for and
+// false if the secret has not been observed yet
+func (s *Store) Registries(secretName string) ([]string, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	auths, found := s.auths[secretName]
+	if !found {
+		return nil, false
+	}
+
+	result := make([]string, 0, len(auths))
+	result = slices.AppendSeq(result, maps.Keys(auths))
+	slices.Sort(result)
+	return result, true
+}
+
+// Auth - returns the credentials of the registry; if several secrets hold
+// credentials for the registry, the secrets are searched in the name order
+func (s *Store) Auth(registry string) (Auth, bool) {
+	if s == nil {
+		return Auth{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, secretName := range slices.Sorted(maps.Keys(s.auths)) {
+		if auth, found := s.auths[secretName][registry]; found {
+			return auth, true
+		}
+	}
+	return Auth{}, false
+}
@@ -0,0 +1,65 @@
+package credentials_test
+
+import (
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := credentials.NewStore()
+
+	_, found := store.Registries("secret")
+	assert.False(t, found)
+
+	require.NoError(t, store.Set("secret", []byte(`{"auths": {"mirror.local": {}}}`)))
+	registries, found := store.Registries("secret")
+	assert.True(t, found)
+	assert.Equal(t, []string{"mirror.local"}, registries)
+
+	// the invalid content serves no registry
+	require.Error(t, store.Set("secret", []byte(`invalid`)))
+	registries, found = store.Registries("secret")
+	assert.True(t, found)
+	assert.Empty(t, registries)
+
+	require.NoError(t, store.Set("secret", []byte(`{"auths": {"mirror.local": {}}}`)))
+	store.Delete("secret")
+	registries, found = store.Registries("secret")
+	assert.True(t, found)
+	assert.Empty(t, registries)
+
+	require.NoError(t, store.Set("secret", []byte(`{"auths": {"mirror.local": {}}}`)))
+	store.Retain([]string{"other"})
+	_, found = store.Registries("secret")
+	assert.False(t, found)
+}
+
+func TestStore_Auth(t *testing.T) {
+	store := credentials.NewStore()
+
+	_, found := store.Auth("mirror.local")
+	assert.False(t, found)
+
+	// 'dXNlcjpwYXNz' - 'user:pass'
+	require.NoError(t, store.Set("b-secret", []byte(`{"auths": {"mirror.local": {"username": "other", "password": "secret"}}}`)))
+	require.NoError(t, store.Set("a-secret", []byte(`{"auths": {
+  "https://mirror.local/v2/": {"auth": "dXNlcjpwYXNz"},
+  "gcr.io": {"identitytoken": "token"}
+}}`)))
+
+	auth, found := store.Auth("mirror.local")
+	assert.True(t, found)
+	assert.Equal(t, credentials.Auth{Username: "user", Password: "pass"}, auth)
+
+	auth, found = store.Auth("gcr.io")
+	assert.True(t, found)
+	assert.Equal(t, credentials.Auth{IdentityToken: "token"}, auth)
+
+	store.Delete("a-secret")
+	auth, found = store.Auth("mirror.local")
+	assert.True(t, found)
+	assert.Equal(t, credentials.Auth{Username: "other", Password: "secret"}, auth)
+}
@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	probeResultSuccess = "success"
+	probeResultFailure = "failure"
+)
+
+type HealthProberOpts struct {
+	// Interval - the time between the probes of a mirror
+	Interval time.Duration
+	// Timeout - the timeout of a single probe
+	Timeout time.Duration
+	// FailureThreshold - the number of the consecutive failed probes a
+	// healthy mirror becomes unhealthy after
+	FailureThreshold int
+	// SuccessThreshold - the number of the consecutive successful probes an
+	// unhealthy mirror becomes healthy after
+	SuccessThreshold int
+	// PlainHTTP - the mirrors probed over HTTP instead of HTTPS
+	PlainHTTP []string
+}
+
+type mirrorState struct {
+	healthy   bool
+	failures  int
+	successes int
+}
+
+var (
+	_ manager.Runnable               = &HealthProber{}
+	_ manager.LeaderElectionRunnable = &HealthProber{}
+)
+
+// HealthProber - probes the mirrors in the background with the
+// 'GET /v2/' request of the OCI distribution specification; the mirrors
+// answering with 200 or 401 (authentication required) are up. The mirrors
+// are healthy until they fail the failure threshold of consecutive probes.
+type HealthProber struct {
+	client *http.Client
+	log    *slog.Logger
+
+	mu      sync.RWMutex
+	opts    HealthProberOpts
+	mirrors map[string]*mirrorState
+	changed chan struct{}
+}
+
+// NewHealthProber - the client defaults to http.DefaultClient
+func NewHealthProber(client *http.Client) *HealthProber {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HealthProber{
+		client:  client,
+		log:     slog.Default().With("log-id", "mirror-health-prober"),
+		mirrors: map[string]*mirrorState{},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// SetTargets - replaces the probed mirrors in the host[:port] format and the
+// probe settings; the state of the mirrors probed already is kept and the new
+// mirrors are probed right away
+func (p *HealthProber) SetTargets(mirrors []string, opts HealthProberOpts) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.opts = opts
+
+	for mirror := range p.mirrors {
+		if slices.Contains(mirrors, mirror) {
+			continue
+		}
+		delete(p.mirrors, mirror)
+		mirrorHealthy.DeleteLabelValues(mirror)
+		mirrorProbes.DeletePartialMatch(map[string]string{"mirror": mirror})
+	}
+
+	for _, mirror := range mirrors {
+		if _, found := p.mirrors[mirror]; found {
+			continue
+		}
+		p.mirrors[mirror] = &mirrorState{healthy: true}
+		mirrorHealthy.WithLabelValues(mirror).Set(1)
+	}
+
+	p.log.Info("mirror health probe targets updated",
+		"mirrors", mirrors,
+		"interval", opts.Interval)
+
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Healthy - returns true if the mirror in the host[:port] format is healthy;
+// the mirrors that are not probed are healthy
+func (p *HealthProber) Healthy(mirror string) bool {
+	if p == nil {
+		return true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state, found := p.mirrors[mirror]
+	return !found || state.healthy
+}
+
+// NeedLeaderElection - all the replicas serve the admission requests
+func (p *HealthProber) NeedLeaderElection() bool {
+	return false
+}
+
+// Start - implements manager.Runnable; probes the mirrors until the context
+// is done
+func (p *HealthProber) Start(ctx context.Context) error {
+	for {
+		p.probeAll(ctx)
+
+		p.mu.RLock()
+		interval := p.opts.Interval
+		p.mu.RUnlock()
+
+		if !p.wait(ctx, interval) {
+			return nil
+		}
+	}
+}
+
+// wait - waits for the interval or the change of the targets, returns false
+// once the context is done; the prober waits for the targets only if there is
+// no interval
+func (p *HealthProber) wait(ctx context.Context, interval time.Duration) bool {
+	var tick <-chan time.Time
+	if interval > 0 {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		tick = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-p.changed:
+	case <-tick:
+	}
+	return true
+}
+
+func (p *HealthProber) probeAll(ctx context.Context) {
+	p.mu.RLock()
+	opts := p.opts
+	mirrors := make([]string, 0, len(p.mirrors))
+	for mirror := range p.mirrors {
+		mirrors = append(mirrors, mirror)
+	}
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, mirror := range mirrors {
+		wg.Go(func() {
+			err := p.probe(ctx, mirror, opts)
+			p.record(mirror, err, opts)
+		})
+	}
+	wg.Wait()
+}
+
+func (p *HealthProber) probe(ctx context.Context, mirror string, opts HealthProberOpts) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	scheme := "https"
+	if slices.Contains(opts.PlainHTTP, mirror) {
+		scheme = "http"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/v2/", scheme, mirror), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("unexpected status '%s'", resp.Status)
+	}
+	return nil
+}
+
+// record - updates the state of the mirror with the result of the probe; the
+// mirrors that are not probed anymore are ignored
+func (p *HealthProber) record(mirror string, err error, opts HealthProberOpts) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, found := p.mirrors[mirror]
+	if !found {
+		return
+	}
+
+	if err != nil {
+		mirrorProbes.WithLabelValues(mirror, probeResultFailure).Inc()
+		state.failures++
+		state.successes = 0
+		p.log.Debug("mirror health probe failed", "mirror", mirror, "error", err)
+
+		if state.healthy && state.failures >= max(opts.FailureThreshold, 1) {
+			state.healthy = false
+			mirrorHealthy.WithLabelValues(mirror).Set(0)
+			p.log.Warn("mirror unhealthy", "mirror", mirror, "failures", state.failures, "error", err)
+		}
+		return
+	}
+
+	mirrorProbes.WithLabelValues(mirror, probeResultSuccess).Inc()
+	state.successes++
+	state.failures = 0
+
+	if !state.healthy && state.successes >= max(opts.SuccessThreshold, 1) {
+		state.healthy = true
+		mirrorHealthy.WithLabelValues(mirror).Set(1)
+		p.log.Info("mirror healthy again", "mirror", mirror, "successes", state.successes)
+	}
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthProber(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusUnauthorized)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	// the closed server refuses the connections
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "http://")
+	unreachable := strings.TrimPrefix(closed.URL, "http://")
+
+	prober := NewHealthProber(nil)
+	prober.SetTargets([]string{mirror, unreachable}, HealthProberOpts{
+		Timeout:          time.Second,
+		FailureThreshold: 2,
+		SuccessThreshold: 2,
+		PlainHTTP:        []string{mirror, unreachable},
+	})
+
+	// the mirrors are healthy until they fail the probes
+	assert.True(t, prober.Healthy(mirror))
+	assert.True(t, prober.Healthy(unreachable))
+	assert.True(t, prober.Healthy("not-probed.local"))
+
+	ctx := context.Background()
+	prober.probeAll(ctx)
+	assert.True(t, prober.Healthy(mirror), "authentication required means up")
+	assert.True(t, prober.Healthy(unreachable), "below the failure threshold")
+
+	prober.probeAll(ctx)
+	assert.False(t, prober.Healthy(unreachable))
+	assert.Equal(t, float64(0), testutil.ToFloat64(mirrorHealthy.WithLabelValues(unreachable)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(mirrorProbes.WithLabelValues(unreachable, probeResultFailure)))
+
+	status.Store(http.StatusServiceUnavailable)
+	prober.probeAll(ctx)
+	prober.probeAll(ctx)
+	assert.False(t, prober.Healthy(mirror))
+	assert.Equal(t, float64(0), testutil.ToFloat64(mirrorHealthy.WithLabelValues(mirror)))
+
+	status.Store(http.StatusOK)
+	prober.probeAll(ctx)
+	assert.False(t, prober.Healthy(mirror), "below the success threshold")
+	prober.probeAll(ctx)
+	assert.True(t, prober.Healthy(mirror))
+	assert.Equal(t, float64(1), testutil.ToFloat64(mirrorHealthy.WithLabelValues(mirror)))
+
+	// the state of the mirrors probed already is kept
+	prober.SetTargets([]string{unreachable}, HealthProberOpts{FailureThreshold: 2})
+	assert.False(t, prober.Healthy(unreachable))
+	assert.True(t, prober.Healthy(mirror))
+}
+
+func TestHealthProber_Start(t *testing.T) {
+	var probes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "http://")
+
+	prober := NewHealthProber(nil)
+	prober.SetTargets([]string{mirror}, HealthProberOpts{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 3,
+		PlainHTTP:        []string{mirror},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- prober.Start(ctx) }()
+
+	require.Eventually(t, func() bool { return !prober.Healthy(mirror) }, 5*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, probes.Load(), int32(3))
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,401 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+)
+
+// dockerHubHost - the host serving the distribution API of Docker Hub
+const dockerHubHost = "registry-1.docker.io"
+
+// maxCacheSize - the maximum number of the cached results; the expired ones
+// are dropped first, then the oldest ones
+const maxCacheSize = 1024
+
+// manifestMediaTypes - the manifests and the indexes accepted by the checks
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Credentials - provides the credentials of the registries
+type Credentials interface {
+	Auth(registry string) (credentials.Auth, bool)
+}
+
+type ManifestCheckerOpts struct {
+	// Client - the client the registries are queried with, defaults to
+	// http.DefaultClient
+	Client *http.Client
+	// Credentials - the credentials of the registries, optional; the
+	// registries are queried anonymously without them
+	Credentials Credentials
+	// TTL - how long the results are cached
+	TTL time.Duration
+	// PlainHTTP - the registries queried over HTTP instead of HTTPS
+	PlainHTTP []string
+}
+
+// manifest - the result of the manifest query
+type manifest struct {
+	exists bool
+	// digest - the digest of the manifest or the index reported by the
+	// registry, e.g. 'sha256:...'
+	digest string
+}
+
+type cachedResult struct {
+	manifest
+	expires time.Time
+}
+
+// ManifestChecker - checks if the images exist in their registries and
+// resolves their digests with the 'HEAD /v2/<name>/manifests/<reference>'
+// request of the OCI distribution specification; the results are cached, the
+// failed queries are not
+type ManifestChecker struct {
+	opts ManifestCheckerOpts
+	now  func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedResult
+}
+
+// ErrManifestNotFound - the manifest of the image does not exist
+var ErrManifestNotFound = errors.New("manifest not found")
+
+func NewManifestChecker(opts ManifestCheckerOpts) *ManifestChecker {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	return &ManifestChecker{
+		opts:  opts,
+		now:   time.Now,
+		cache: map[string]cachedResult{},
+	}
+}
+
+// Exists - returns true if the manifest of the image exists in its registry
+func (c *ManifestChecker) Exists(ctx context.Context, image string) (bool, error) {
+	result, err := c.manifest(ctx, image)
+	if err != nil {
+		return false, err
+	}
+	return result.exists, nil
+}
+
+// Digest - returns the digest of the manifest the image refers to, e.g. the
+// digest of the index of a multi-platform image; ErrManifestNotFound is
+// returned if the manifest does not exist
+func (c *ManifestChecker) Digest(ctx context.Context, image string) (string, error) {
+	result, err := c.manifest(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	if !result.exists {
+		return "", fmt.Errorf("image '%s': %w", image, ErrManifestNotFound)
+	}
+	if result.digest == "" {
+		return "", fmt.Errorf("image '%s': the registry did not report the digest", image)
+	}
+	return result.digest, nil
+}
+
+func (c *ManifestChecker) manifest(ctx context.Context, image string) (manifest, error) {
+	name, err := k8s.ParseImage(image)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	if result, found := c.cached(image); found {
+		return result, nil
+	}
+
+	result, err := c.headManifest(ctx, name)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	slog.Debug("image manifest checked",
+		"image-name", image,
+		"exists", result.exists,
+		"digest", result.digest)
+
+	c.store(image, result)
+	return result, nil
+}
+
+func (c *ManifestChecker) cached(image string) (manifest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, found := c.cache[image]
+	if !found || !c.now().Before(result.expires) {
+		return manifest{}, false
+	}
+	return result.manifest, true
+}
+
+func (c *ManifestChecker) store(image string, result manifest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, found := c.cache[image]; !found && len(c.cache) >= maxCacheSize {
+		c.evict(now)
+	}
+
+	c.cache[image] = cachedResult{
+		manifest: result,
+		expires:  now.Add(c.opts.TTL),
+	}
+}
+
+// evict - drops the expired results or, if none expired, the oldest one; all
+// the results are cached for the same TTL, so the oldest expires first
+func (c *ManifestChecker) evict(now time.Time) {
+	var oldest string
+	for key, result := range c.cache {
+		if !now.Before(result.expires) {
+			delete(c.cache, key)
+			continue
+		}
+		if oldest == "" || result.expires.Before(c.cache[oldest].expires) {
+			oldest = key
+		}
+	}
+
+	if len(c.cache) >= maxCacheSize {
+		delete(c.cache, oldest)
+	}
+}
+
+// manifestURL - returns the URL of the manifest of the image; the digest
+// takes precedence over the tag
+func (c *ManifestChecker) manifestURL(name k8s.ImageName) string {
+	scheme := "https"
+	if slices.Contains(c.opts.PlainHTTP, name.Registry) {
+		scheme = "http"
+	}
+
+	host := name.Registry
+	if host == k8s.DefaultRegistry {
+		host = dockerHubHost
+	}
+
+	ref := "latest"
+	if _, digest, found := strings.Cut(name.Suffix, "@"); found {
+		ref = digest
+	} else if tag, found := strings.CutPrefix(name.Suffix, ":"); found {
+		ref = tag
+	}
+
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, name.Repository, ref)
+}
+
+func (c *ManifestChecker) headManifest(ctx context.Context, name k8s.ImageName) (manifest, error) {
+	manifestURL := c.manifestURL(name)
+
+	var auth credentials.Auth
+	if c.opts.Credentials != nil {
+		auth, _ = c.opts.Credentials.Auth(name.Registry)
+	}
+
+	var authorization string
+	if auth.RegistryToken != "" {
+		authorization = "Bearer " + auth.RegistryToken
+	}
+
+	resp, err := c.head(ctx, manifestURL, authorization)
+	if err != nil {
+		return manifest{}, err
+	}
+
+	// the registry tells how to authenticate in the challenge
+	if resp.StatusCode == http.StatusUnauthorized && authorization == "" {
+		authorization, err = c.authorize(ctx, resp.Header.Get("WWW-Authenticate"), name.Repository, auth)
+		if err != nil {
+			return manifest{}, err
+		}
+
+		resp, err = c.head(ctx, manifestURL, authorization)
+		if err != nil {
+			return manifest{}, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return manifest{
+			exists: true,
+			digest: resp.Header.Get("Docker-Content-Digest"),
+		}, nil
+	case http.StatusNotFound:
+		return manifest{}, nil
+	default:
+		return manifest{}, fmt.Errorf("unexpected status '%s' of '%s'", resp.Status, manifestURL)
+	}
+}
+
+func (c *ManifestChecker) head(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// authorize - returns the authorization header answering the challenge of
+// the registry; the bearer tokens are requested for pulling the repository
+func (c *ManifestChecker) authorize(
+	ctx context.Context,
+	challenge, repository string,
+	auth credentials.Auth) (string, error) {
+
+	scheme, params := parseChallenge(challenge)
+
+	switch scheme {
+	case "basic":
+		if auth.Username == "" {
+			return "", errors.New("the registry requires credentials")
+		}
+		return "Basic " + basicAuth(auth), nil
+	case "bearer":
+		token, err := c.token(ctx, params, "repository:"+repository+":pull", auth)
+		if err != nil {
+			return "", fmt.Errorf("unable to get registry token: %w", err)
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge '%s'", challenge)
+	}
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// token - requests the bearer token from the authorization server named by
+// the challenge; the identity token is exchanged with the OAuth2 refresh
+// token grant, other credentials are sent with the basic authentication
+func (c *ManifestChecker) token(
+	ctx context.Context,
+	params map[string]string,
+	scope string,
+	auth credentials.Auth) (string, error) {
+
+	realm := params["realm"]
+	if realm == "" {
+		return "", errors.New("the challenge has no realm")
+	}
+
+	query := url.Values{"scope": []string{scope}}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	var req *http.Request
+	var err error
+	if auth.IdentityToken != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", auth.IdentityToken)
+		query.Set("client_id", "rt-bootstrapper")
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm, strings.NewReader(query.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if auth.Username != "" {
+			req.Header.Set("Authorization", "Basic "+basicAuth(auth))
+		}
+	}
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status '%s' of '%s'", resp.Status, realm)
+	}
+
+	var result tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+
+	if result.Token != "" {
+		return result.Token, nil
+	}
+	if result.AccessToken != "" {
+		return result.AccessToken, nil
+	}
+	return "", errors.New("the token response has no token")
+}
+
+func basicAuth(auth credentials.Auth) string {
+	return base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+}
+
+// parseChallenge - returns the lower case scheme and the parameters of the
+// 'WWW-Authenticate' header, e.g. 'Bearer realm="...",service="..."'
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if quoted, ok := strings.CutPrefix(value, `"`); ok {
+			// the quoted values can contain commas, e.g. the scopes
+			value, rest, _ = strings.Cut(quoted, `"`)
+			_, rest, _ = strings.Cut(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+
+	return strings.ToLower(scheme), params
+}
@@ -0,0 +1,321 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCredentials map[string]credentials.Auth
+
+func (c testCredentials) Auth(registry string) (credentials.Auth, bool) {
+	auth, found := c[registry]
+	return auth, found
+}
+
+// newTestRegistry - serves the manifests of the given paths, e.g.
+// '/v2/library/nginx/manifests/latest', to the authorized requests only
+func newTestRegistry(
+	manifests []string,
+	authorized func(r *http.Request) bool,
+	challenge func() string) (*http.ServeMux, *atomic.Int32) {
+
+	var requests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if !authorized(r) {
+			w.Header().Set("WWW-Authenticate", challenge())
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		for _, manifest := range manifests {
+			if r.URL.Path == manifest {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return mux, &requests
+}
+
+func TestManifestChecker_Exists(t *testing.T) {
+	manifests := []string{
+		"/v2/library/nginx/manifests/latest",
+		"/v2/project/app/manifests/v1",
+		"/v2/project/app/manifests/sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+
+	anonymous, _ := newTestRegistry(manifests, func(*http.Request) bool { return true }, nil)
+	anonymousSrv := httptest.NewTLSServer(anonymous)
+	defer anonymousSrv.Close()
+
+	basic, _ := newTestRegistry(manifests, func(r *http.Request) bool {
+		username, password, ok := r.BasicAuth()
+		return ok && username == "user" && password == "pass"
+	}, func() string { return `Basic realm="registry"` })
+	basicSrv := httptest.NewServer(basic)
+	defer basicSrv.Close()
+
+	// the challenge names the authorization server of the test case
+	var bearerChallenge string
+	bearer, _ := newTestRegistry(manifests, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer pull-token"
+	}, func() string { return bearerChallenge })
+	bearerSrv := httptest.NewServer(bearer)
+	defer bearerSrv.Close()
+
+	bearer.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" ||
+			r.URL.Query().Get("service") != "test-registry" ||
+			r.URL.Query().Get("scope") != "repository:project/app:pull" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token": "pull-token"}`))
+	})
+	bearer.HandleFunc("/oauth", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "refresh_token" ||
+			r.FormValue("refresh_token") != "identity" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "pull-token"}`))
+	})
+
+	anonymousHost := strings.TrimPrefix(anonymousSrv.URL, "https://")
+	basicHost := strings.TrimPrefix(basicSrv.URL, "http://")
+	bearerHost := strings.TrimPrefix(bearerSrv.URL, "http://")
+
+	tcs := []struct {
+		name      string
+		image     string
+		challenge string
+		creds     testCredentials
+		exists    bool
+		err       string
+	}{
+		{
+			name:   "anonymous registry, tagged image",
+			image:  anonymousHost + "/project/app:v1",
+			exists: true,
+		},
+		{
+			name:   "anonymous registry, image without a tag",
+			image:  anonymousHost + "/library/nginx",
+			exists: true,
+		},
+		{
+			name:   "anonymous registry, digest takes precedence",
+			image:  anonymousHost + "/project/app:v2@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			exists: true,
+		},
+		{
+			name:  "anonymous registry, missing image",
+			image: anonymousHost + "/project/app:v2",
+		},
+		{
+			name:   "basic authentication",
+			image:  basicHost + "/project/app:v1",
+			creds:  testCredentials{basicHost: {Username: "user", Password: "pass"}},
+			exists: true,
+		},
+		{
+			name:  "basic authentication, missing image",
+			image: basicHost + "/project/other:v1",
+			creds: testCredentials{basicHost: {Username: "user", Password: "pass"}},
+		},
+		{
+			name:  "basic authentication, no credentials",
+			image: basicHost + "/project/app:v1",
+			err:   "the registry requires credentials",
+		},
+		{
+			name:  "basic authentication, invalid credentials",
+			image: basicHost + "/project/app:v1",
+			creds: testCredentials{basicHost: {Username: "user", Password: "invalid"}},
+			err:   "unexpected status '401 Unauthorized'",
+		},
+		{
+			name:      "bearer token",
+			image:     bearerHost + "/project/app:v1",
+			challenge: `Bearer realm="` + bearerSrv.URL + `/token",service="test-registry",scope="repository:project/app:pull,push"`,
+			creds:     testCredentials{bearerHost: {Username: "user", Password: "pass"}},
+			exists:    true,
+		},
+		{
+			name:      "bearer token exchanged for the identity token",
+			image:     bearerHost + "/project/app:v1",
+			challenge: `Bearer realm="` + bearerSrv.URL + `/oauth",service="test-registry"`,
+			creds:     testCredentials{bearerHost: {IdentityToken: "identity"}},
+			exists:    true,
+		},
+		{
+			name:   "registry token",
+			image:  bearerHost + "/project/app:v1",
+			creds:  testCredentials{bearerHost: {RegistryToken: "pull-token"}},
+			exists: true,
+		},
+		{
+			name:      "bearer token, invalid credentials",
+			image:     bearerHost + "/project/app:v1",
+			challenge: `Bearer realm="` + bearerSrv.URL + `/token",service="test-registry"`,
+			creds:     testCredentials{bearerHost: {Username: "user", Password: "invalid"}},
+			err:       "unable to get registry token",
+		},
+		{
+			name:  "invalid image",
+			image: anonymousHost + "/Project/app:v1",
+			err:   "invalid reference format",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			bearerChallenge = tc.challenge
+
+			checker := NewManifestChecker(ManifestCheckerOpts{
+				Client:      anonymousSrv.Client(),
+				Credentials: tc.creds,
+				TTL:         time.Minute,
+				PlainHTTP:   []string{basicHost, bearerHost},
+			})
+
+			exists, err := checker.Exists(context.Background(), tc.image)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exists, exists)
+		})
+	}
+}
+
+func TestManifestChecker_cache(t *testing.T) {
+	manifests := []string{"/v2/project/app/manifests/v1"}
+	mux, requests := newTestRegistry(manifests, func(*http.Request) bool { return true }, nil)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	checker := NewManifestChecker(ManifestCheckerOpts{
+		TTL:       time.Minute,
+		PlainHTTP: []string{host},
+	})
+
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	for _, image := range []string{host + "/project/app:v1", host + "/project/app:v2"} {
+		expected := image == host+"/project/app:v1"
+
+		exists, err := checker.Exists(context.Background(), image)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists)
+
+		// the existing and the missing images are cached
+		exists, err = checker.Exists(context.Background(), image)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists)
+	}
+	assert.Equal(t, int32(2), requests.Load())
+
+	// the registry is queried again once the results expire
+	now = now.Add(time.Minute)
+	_, err := checker.Exists(context.Background(), host+"/project/app:v2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// the failed checks are not cached
+	srv.Close()
+	now = now.Add(time.Minute)
+	for range 2 {
+		_, err = checker.Exists(context.Background(), host+"/project/app:v1")
+		require.Error(t, err)
+	}
+}
+
+func TestManifestChecker_cacheSize(t *testing.T) {
+	checker := NewManifestChecker(ManifestCheckerOpts{TTL: time.Hour})
+
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	image := func(i int) string {
+		return fmt.Sprintf("mirror.local/app:v%d", i)
+	}
+
+	for i := range maxCacheSize + 1 {
+		checker.store(image(i), manifest{exists: true})
+		now = now.Add(time.Second)
+	}
+
+	// none of the results expired, the oldest one is evicted
+	assert.Equal(t, maxCacheSize, len(checker.cache))
+	_, found := checker.cached(image(0))
+	assert.False(t, found)
+	_, found = checker.cached(image(maxCacheSize))
+	assert.True(t, found)
+
+	// the expired results are dropped first, the results of the first half
+	// of the images expired
+	now = now.Add(time.Hour - time.Duration(maxCacheSize/2)*time.Second)
+	checker.store(image(maxCacheSize+1), manifest{exists: true})
+	assert.Equal(t, maxCacheSize/2, len(checker.cache))
+	_, found = checker.cached(image(maxCacheSize))
+	assert.True(t, found)
+}
+
+func TestManifestChecker_Digest(t *testing.T) {
+	const digest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/app/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusOK)
+		case "/v2/project/app/manifests/no-digest":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	checker := NewManifestChecker(ManifestCheckerOpts{
+		TTL:       time.Minute,
+		PlainHTTP: []string{host},
+	})
+
+	actual, err := checker.Digest(context.Background(), host+"/project/app:v1")
+	require.NoError(t, err)
+	assert.Equal(t, digest, actual)
+
+	_, err = checker.Digest(context.Background(), host+"/project/app:v2")
+	require.ErrorIs(t, err, ErrManifestNotFound)
+
+	_, err = checker.Digest(context.Background(), host+"/project/app:no-digest")
+	require.ErrorContains(t, err, "the registry did not report the digest")
+}
@@ -0,0 +1,22 @@
+package registry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	mirrorHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rt_bootstrapper_mirror_healthy",
+		Help: "Whether the mirror is healthy (1) or not (0) according to the health probes.",
+	}, []string{"mirror"})
+
+	mirrorProbes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rt_bootstrapper_mirror_probes_total",
+		Help: "The number of the mirror health probes by their result.",
+	}, []string{"mirror", "result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(mirrorHealthy, mirrorProbes)
+}
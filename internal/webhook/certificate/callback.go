@@ -13,9 +13,9 @@ import (
 )
 
 type BuildUpdateCABundleOpts struct {
-	// Name of the validating webhook configuration to be updated
+	// Name of the webhook configuration to be updated
 	Name string
-	// CABundle the validating webhook configuration webhooks will be updated with
+	// CABundle the webhook configuration webhooks will be updated with
 	CABundle []byte
 	// FieldManager the name of the filed manager for patch operation
 	FieldManager string
@@ -27,43 +27,106 @@ func BuildUpdateCABundle(
 	rtClient client.Client,
 	opts BuildUpdateCABundleOpts) func() error {
 
+	return buildUpdateWebhooksCABundle(ctx, rtClient, opts, mutatingWebhookConfiguration)
+}
+
+// BuildUpdateValidatingCABundle - builds a function that will update
+// certificate authority of the validating webhook configuration
+func BuildUpdateValidatingCABundle(
+	ctx context.Context,
+	rtClient client.Client,
+	opts BuildUpdateCABundleOpts) func() error {
+
+	return buildUpdateWebhooksCABundle(ctx, rtClient, opts, validatingWebhookConfiguration)
+}
+
+// webhookConfigurationKind - the access to the client configurations of the
+// webhooks of a webhook configuration kind
+type webhookConfigurationKind[T client.Object] struct {
+	kind        string
+	description string
+	newObject   func() T
+	// clientConfigs - returns the pointers to the client configurations of
+	// all the webhooks of the configuration
+	clientConfigs func(T) []*admissionregistration.WebhookClientConfig
+}
+
+var (
+	mutatingWebhookConfiguration = webhookConfigurationKind[*admissionregistration.MutatingWebhookConfiguration]{
+		kind:        "MutatingWebhookConfiguration",
+		description: "mutating webhook configuration",
+		newObject: func() *admissionregistration.MutatingWebhookConfiguration {
+			return &admissionregistration.MutatingWebhookConfiguration{}
+		},
+		clientConfigs: func(cfg *admissionregistration.MutatingWebhookConfiguration) []*admissionregistration.WebhookClientConfig {
+			result := make([]*admissionregistration.WebhookClientConfig, 0, len(cfg.Webhooks))
+			for i := range cfg.Webhooks {
+				result = append(result, &cfg.Webhooks[i].ClientConfig)
+			}
+			return result
+		},
+	}
+
+	validatingWebhookConfiguration = webhookConfigurationKind[*admissionregistration.ValidatingWebhookConfiguration]{
+		kind:        "ValidatingWebhookConfiguration",
+		description: "validating webhook configuration",
+		newObject: func() *admissionregistration.ValidatingWebhookConfiguration {
+			return &admissionregistration.ValidatingWebhookConfiguration{}
+		},
+		clientConfigs: func(cfg *admissionregistration.ValidatingWebhookConfiguration) []*admissionregistration.WebhookClientConfig {
+			result := make([]*admissionregistration.WebhookClientConfig, 0, len(cfg.Webhooks))
+			for i := range cfg.Webhooks {
+				result = append(result, &cfg.Webhooks[i].ClientConfig)
+			}
+			return result
+		},
+	}
+)
+
+// buildUpdateWebhooksCABundle - builds a function that will update the
+// certificate authority of all the webhooks of the webhook configuration
+func buildUpdateWebhooksCABundle[T client.Object](
+	ctx context.Context,
+	rtClient client.Client,
+	opts BuildUpdateCABundleOpts,
+	kind webhookConfigurationKind[T]) func() error {
+
 	logger := slog.Default()
 	return func() error {
 		getCtx, cancelGet := context.WithTimeout(ctx, 5*time.Second)
 		defer cancelGet()
 
-		var mutatingWebhook admissionregistration.MutatingWebhookConfiguration
+		webhookCfg := kind.newObject()
 		if err := rtClient.Get(
 			getCtx,
 			client.ObjectKey{Name: opts.Name},
-			&mutatingWebhook); err != nil {
-			return fmt.Errorf("unable to get mutating webhook configuration: %w", err)
+			webhookCfg); err != nil {
+			return fmt.Errorf("unable to get %s: %w", kind.description, err)
 		}
 
 		var updated bool
-		for i := 0; i < len(mutatingWebhook.Webhooks); i++ {
-			if bytes.Equal(opts.CABundle, mutatingWebhook.Webhooks[i].ClientConfig.CABundle) {
+		for _, clientConfig := range kind.clientConfigs(webhookCfg) {
+			if bytes.Equal(opts.CABundle, clientConfig.CABundle) {
 				continue
 			}
-			mutatingWebhook.Webhooks[i].ClientConfig.CABundle = opts.CABundle
+			clientConfig.CABundle = opts.CABundle
 			updated = true
 		}
 
 		if !updated {
-			logger.Info("mutating webhook configuration up to date")
+			logger.Info(kind.description + " up to date")
 			return nil
 		}
 
-		mutatingWebhook.Kind = "MutatingWebhookConfiguration"
-		mutatingWebhook.APIVersion = "admissionregistration.k8s.io/v1"
-		mutatingWebhook.ManagedFields = nil
+		webhookCfg.GetObjectKind().SetGroupVersionKind(admissionregistration.SchemeGroupVersion.WithKind(kind.kind))
+		webhookCfg.SetManagedFields(nil)
 
 		patchCtx, cancelPatch := context.WithTimeout(ctx, 5*time.Second)
 		defer cancelPatch()
 
-		logger.Info("attempting to patch mutating webhook configuration", "name", mutatingWebhook.Name)
+		logger.Info("attempting to patch "+kind.description, "name", webhookCfg.GetName())
 
-		return rtClient.Patch(patchCtx, &mutatingWebhook, client.Apply, &client.PatchOptions{
+		return rtClient.Patch(patchCtx, webhookCfg, client.Apply, &client.PatchOptions{
 			FieldManager: opts.FieldManager,
 			Force:        ptr.To(true),
 		})
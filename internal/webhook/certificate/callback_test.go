@@ -125,3 +125,59 @@ func buildPatchFake(c *admissionregistration.MutatingWebhookConfiguration) func(
 		return nil
 	}
 }
+
+func Test_BuildUpdateValidatingCABundle(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+
+	vWhCfg := admissionregistration.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-me",
+		},
+		Webhooks: []admissionregistration.ValidatingWebhook{
+			{
+				ClientConfig: admissionregistration.WebhookClientConfig{
+					CABundle: []byte("test-me"),
+				},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithObjects(&vWhCfg).
+		WithScheme(scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context,
+				clnt client.WithWatch,
+				obj client.Object,
+				patch client.Patch, opts ...client.PatchOption) error {
+
+				if patch.Type() != types.ApplyPatchType {
+					return clnt.Patch(ctx, obj, patch, opts...)
+				}
+
+				patched, ok := obj.(*admissionregistration.ValidatingWebhookConfiguration)
+				if !ok {
+					return fmt.Errorf("failed to cast object to validating webhook configuration")
+				}
+
+				vWhCfg = *patched
+				return nil
+			},
+		}).Build()
+
+	err := certificate.BuildUpdateValidatingCABundle(ctx, fakeClient, certificate.BuildUpdateCABundleOpts{
+		Name:     "test-me",
+		CABundle: []byte("updated"),
+	})()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("updated"), vWhCfg.Webhooks[0].ClientConfig.CABundle)
+
+	err = certificate.BuildUpdateValidatingCABundle(ctx, fakeClient, certificate.BuildUpdateCABundleOpts{
+		Name:     "missing",
+		CABundle: []byte("updated"),
+	})()
+
+	assert.ErrorContains(t, err, "unable to get validating webhook configuration")
+}
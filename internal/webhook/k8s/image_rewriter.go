@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/distribution/reference"
+)
+
+// ImageRewriteRule - rewrites the image repositories the expression matches
+// as a whole, the expression is expected to be anchored
+type ImageRewriteRule struct {
+	Name        string
+	Regex       *regexp.Regexp
+	Replacement string
+}
+
+// ImageRewrite - explains how the image was rewritten
+type ImageRewrite struct {
+	Image  string
+	Result string
+	// Rule - the override key ('overrides[<key>]'), the fallback mirror of
+	// the override ('fallbacks[<key>][<index>]') or the name of the rewrite
+	// rule applied to the image, empty if the image was not rewritten
+	Rule string
+}
+
+func (r ImageRewrite) String() string {
+	if r.Rule == "" {
+		return fmt.Sprintf("%s: no rule matched", r.Image)
+	}
+	return fmt.Sprintf("%s: %s -> %s", r.Image, r.Rule, r.Result)
+}
+
+// ImageRewriter - rewrites the images with the overrides and, if none of them
+// matches, with the first matching rewrite rule.
+//
+// The rules are matched against the repository of the image including its
+// registry, e.g. 'quay.io/org/img' for 'quay.io/org/img:v1'. The Docker Hub
+// repositories are fully qualified, e.g. 'docker.io/library/nginx' for
+// 'nginx'. The tag and the digest of the image are kept.
+type ImageRewriter struct {
+	overrides map[string]string
+	rules     []ImageRewriteRule
+	// fallbacks - the mirrors used instead of the target of the override if
+	// it is not healthy, in the priority order, keyed by the override key
+	fallbacks map[string][]string
+	healthy   MirrorHealth
+}
+
+// MirrorHealth - returns true if the mirror in the host[:port] format is
+// healthy
+type MirrorHealth func(mirror string) bool
+
+func NewImageRewriter(overrides map[string]string, rules []ImageRewriteRule) *ImageRewriter {
+	return &ImageRewriter{
+		overrides: overrides,
+		rules:     rules,
+	}
+}
+
+// WithFailover - returns the rewriter replacing the targets of the overrides
+// that are not healthy with the first healthy fallback mirror; the target of
+// the override is kept if none of the mirrors is healthy
+func (r *ImageRewriter) WithFailover(fallbacks map[string][]string, healthy MirrorHealth) *ImageRewriter {
+	result := *r
+	result.fallbacks = fallbacks
+	result.healthy = healthy
+	return &result
+}
+
+// overrideTarget - returns the first healthy mirror of the override and the
+// rule it is chosen by
+func (r *ImageRewriter) overrideTarget(key string) (string, string) {
+	target, rule := r.overrides[key], fmt.Sprintf("overrides[%s]", key)
+
+	fallbacks := r.fallbacks[key]
+	if len(fallbacks) == 0 || r.healthy == nil || r.healthy(mirrorHost(target)) {
+		return target, rule
+	}
+
+	for i, fallback := range fallbacks {
+		if r.healthy(mirrorHost(fallback)) {
+			return fallback, fmt.Sprintf("fallbacks[%s][%d]", key, i)
+		}
+	}
+	return target, rule
+}
+
+// mirrorHost - returns the host[:port] of the mirror in the
+// host[:port][/path] format
+func mirrorHost(mirror string) string {
+	host, _, _ := strings.Cut(mirror, "/")
+	return host
+}
+
+// Rewrite - returns the rewritten image
+func (r *ImageRewriter) Rewrite(image string) (string, error) {
+	rewrite, err := r.Explain(image)
+	return rewrite.Result, err
+}
+
+// Explain - rewrites the image and reports the rule applied; both the image
+// and the result have to be valid image references
+func (r *ImageRewriter) Explain(image string) (ImageRewrite, error) {
+	result := ImageRewrite{
+		Image:  image,
+		Result: image,
+	}
+
+	if r == nil {
+		return result, nil
+	}
+
+	name, err := ParseImage(image)
+	if err != nil {
+		return result, err
+	}
+
+	if key, rest, found := lookupOverride(name.Registry, name.Repository, r.overrides); found {
+		target, rule := r.overrideTarget(key)
+		result.Result = target + rest + name.Suffix
+		result.Rule = rule
+		return result, validateRewrite(result)
+	}
+
+	repository := name.Registry + "/" + name.Repository
+	for _, rule := range r.rules {
+		if !rule.Regex.MatchString(repository) {
+			continue
+		}
+
+		result.Result = rule.Regex.ReplaceAllString(repository, rule.Replacement) + name.Suffix
+		result.Rule = rule.Name
+		return result, validateRewrite(result)
+	}
+
+	return result, nil
+}
+
+// validateRewrite - the overrides and the rewrite rules can produce malformed
+// references, e.g. with an upper case replacement
+func validateRewrite(rewrite ImageRewrite) error {
+	if _, err := reference.ParseNormalizedNamed(rewrite.Result); err != nil {
+		return fmt.Errorf("%s produced invalid image reference '%s' from '%s': %w",
+			rewrite.Rule, rewrite.Result, rewrite.Image, err)
+	}
+	return nil
+}
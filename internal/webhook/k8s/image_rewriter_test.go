@@ -0,0 +1,359 @@
+package k8s_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRewriter_Explain(t *testing.T) {
+	rewriter := k8s.NewImageRewriter(
+		map[string]string{
+			"quay.io/prometheus": "mirror.local/prometheus",
+		},
+		[]k8s.ImageRewriteRule{
+			{
+				Name:        "quay",
+				Regex:       regexp.MustCompile(`^(?:quay\.io/([^/]+)/([^/]+))$`),
+				Replacement: "mirror.local/quay-$1-$2",
+			},
+			{
+				Name:        "quay-nested",
+				Regex:       regexp.MustCompile(`^(?:quay\.io/(.+))$`),
+				Replacement: "mirror.local/quay/$1",
+			},
+			{
+				Name:        "docker-hub",
+				Regex:       regexp.MustCompile(`^(?:docker\.io/(.+))$`),
+				Replacement: "mirror.local/hub/$1",
+			},
+		})
+
+	tcs := []struct {
+		image    string
+		expected k8s.ImageRewrite
+		explain  string
+	}{
+		{
+			image: "quay.io/org/img:v1",
+			expected: k8s.ImageRewrite{
+				Image:  "quay.io/org/img:v1",
+				Result: "mirror.local/quay-org-img:v1",
+				Rule:   "quay",
+			},
+			explain: "quay.io/org/img:v1: quay -> mirror.local/quay-org-img:v1",
+		},
+		{
+			image: "quay.io/org/team/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			expected: k8s.ImageRewrite{
+				Image:  "quay.io/org/team/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				Result: "mirror.local/quay/org/team/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				Rule:   "quay-nested",
+			},
+			explain: "quay.io/org/team/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa: quay-nested -> mirror.local/quay/org/team/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			image: "quay.io/prometheus/node-exporter:v1",
+			expected: k8s.ImageRewrite{
+				Image:  "quay.io/prometheus/node-exporter:v1",
+				Result: "mirror.local/prometheus/node-exporter:v1",
+				Rule:   "overrides[quay.io/prometheus]",
+			},
+			explain: "quay.io/prometheus/node-exporter:v1: overrides[quay.io/prometheus] -> mirror.local/prometheus/node-exporter:v1",
+		},
+		{
+			image: "nginx",
+			expected: k8s.ImageRewrite{
+				Image:  "nginx",
+				Result: "mirror.local/hub/library/nginx:latest",
+				Rule:   "docker-hub",
+			},
+			explain: "nginx: docker-hub -> mirror.local/hub/library/nginx:latest",
+		},
+		{
+			image: "gcr.io/project/img:v1",
+			expected: k8s.ImageRewrite{
+				Image:  "gcr.io/project/img:v1",
+				Result: "gcr.io/project/img:v1",
+			},
+			explain: "gcr.io/project/img:v1: no rule matched",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.image, func(t *testing.T) {
+			actual, err := rewriter.Explain(tc.image)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.explain, actual.String())
+		})
+	}
+}
+
+func TestImageRewriter_WithFailover(t *testing.T) {
+	healthy := map[string]bool{
+		"mirror-a.local":      true,
+		"mirror-b.local:5000": true,
+	}
+
+	rewriter := k8s.NewImageRewriter(map[string]string{
+		"gcr.io":  "mirror-a.local/gcr",
+		"quay.io": "mirror-c.local",
+	}, nil).WithFailover(map[string][]string{
+		"gcr.io":  {"mirror-c.local", "mirror-b.local:5000/gcr"},
+		"quay.io": {"mirror-d.local"},
+	}, func(mirror string) bool { return healthy[mirror] })
+
+	tcs := []struct {
+		name     string
+		down     []string
+		image    string
+		expected string
+	}{
+		{
+			name:     "healthy target of the override",
+			image:    "gcr.io/project/app:v1",
+			expected: "gcr.io/project/app:v1: overrides[gcr.io] -> mirror-a.local/gcr/project/app:v1",
+		},
+		{
+			name:     "first healthy fallback mirror",
+			down:     []string{"mirror-a.local"},
+			image:    "gcr.io/project/app:v1",
+			expected: "gcr.io/project/app:v1: fallbacks[gcr.io][1] -> mirror-b.local:5000/gcr/project/app:v1",
+		},
+		{
+			name:     "no healthy mirror",
+			image:    "quay.io/org/app:v1",
+			expected: "quay.io/org/app:v1: overrides[quay.io] -> mirror-c.local/org/app:v1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, mirror := range tc.down {
+				healthy[mirror] = false
+				defer func() { healthy[mirror] = true }()
+			}
+
+			actual, err := rewriter.Explain(tc.image)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual.String())
+		})
+	}
+}
+
+func TestImageRewriter_invalidReferences(t *testing.T) {
+	rewriter := k8s.NewImageRewriter(
+		map[string]string{
+			"gcr.io": "mirror.local",
+		},
+		[]k8s.ImageRewriteRule{
+			{
+				Name:        "quay",
+				Regex:       regexp.MustCompile(`^(?:quay\.io/(.+))$`),
+				Replacement: "mirror.local/QUAY/$1",
+			},
+		})
+
+	tcs := []struct {
+		image    string
+		expected string
+	}{
+		{
+			image:    "gcr.io/Project/image:v1",
+			expected: "invalid image reference 'gcr.io/Project/image:v1': invalid reference format: repository name (Project/image) must be lowercase",
+		},
+		{
+			image:    "gcr.io/project/image:v1:v2",
+			expected: "invalid image reference 'gcr.io/project/image:v1:v2': invalid reference format",
+		},
+		{
+			image:    "gcr.io/project/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			expected: "invalid image reference 'gcr.io/project/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa': invalid checksum digest length",
+		},
+		{
+			image:    "quay.io/org/image:v1",
+			expected: "quay produced invalid image reference 'mirror.local/QUAY/org/image:v1' from 'quay.io/org/image:v1': invalid reference format: repository name (QUAY/org/image) must be lowercase",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.image, func(t *testing.T) {
+			_, err := rewriter.Rewrite(tc.image)
+			require.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestImageRewriter_Rewrite_overrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		image     string
+		overrides map[string]string
+		expected  string
+	}{
+		{
+			name:      "Image without registry",
+			image:     "nginx:latest",
+			overrides: map[string]string{"nginx": "should-not-be-replaced"},
+			expected:  "nginx:latest",
+		},
+		{
+			name:      "Image with custom registry",
+			image:     "custom.registry.com/myapp/image:v1.0",
+			overrides: map[string]string{"custom.registry.com": "myregistry.com"},
+			expected:  "myregistry.com/myapp/image:v1.0",
+		},
+		{
+			name:      "Image with multiple slashes with no registry",
+			image:     "namespace/subnamespace/image:tag",
+			overrides: map[string]string{"namespace": "should-not-be-replaced"},
+			expected:  "namespace/subnamespace/image:tag",
+		},
+		{
+			name:      "Image with port in registry",
+			image:     "registry:5000/myapp/image:v2.0",
+			overrides: map[string]string{"registry:5000": "myregistry.com"},
+			expected:  "myregistry.com/myapp/image:v2.0",
+		},
+		{
+			name:      "Image with no tag",
+			image:     "myapp/image",
+			overrides: map[string]string{"myapp": "should-not-be-replaced"},
+			expected:  "myapp/image",
+		},
+		{
+			name:      "Image with localhost registry",
+			image:     "localhost/myapp/image:v3.0",
+			overrides: map[string]string{"localhost": "myregistry.com"},
+			expected:  "myregistry.com/myapp/image:v3.0",
+		},
+		{
+			name:      "Image with port in registry and tag",
+			image:     "registry:5000/image:5000",
+			overrides: map[string]string{"registry:5000": "myregistry.com"},
+			expected:  "myregistry.com/image:5000",
+		},
+		{
+			name:      "Image with tag and digest",
+			image:     "registry.com/image:v1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			overrides: map[string]string{"registry.com": "myregistry.com"},
+			expected:  "myregistry.com/image:v1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			name:      "Image with IP address registry",
+			image:     "192.168.0.1/myapp/image:v4.0",
+			overrides: map[string]string{"192.168.0.1": "myregistry.com"},
+			expected:  "myregistry.com/myapp/image:v4.0",
+		},
+		{
+			name:      "Image with multiple slashes",
+			image:     "registry.com/namespace/subnamespace/image:tag",
+			overrides: map[string]string{"registry.com": "myregistry.com"},
+			expected:  "myregistry.com/namespace/subnamespace/image:tag",
+		},
+		{
+			name:      "Docker Hub official image",
+			image:     "nginx",
+			overrides: map[string]string{"docker.io": "mirror.local"},
+			expected:  "mirror.local/library/nginx:latest",
+		},
+		{
+			name:      "Docker Hub image with namespace and tag",
+			image:     "bitnami/redis:7.2",
+			overrides: map[string]string{"index.docker.io": "mirror.local"},
+			expected:  "mirror.local/bitnami/redis:7.2",
+		},
+		{
+			name:      "Docker Hub image with explicit registry alias",
+			image:     "index.docker.io/nginx@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			overrides: map[string]string{"docker.io": "mirror.local:5000"},
+			expected:  "mirror.local:5000/library/nginx@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			name:      "Docker Hub image with explicit registry",
+			image:     "docker.io/library/nginx:1.27",
+			overrides: map[string]string{"docker.io": "mirror.local"},
+			expected:  "mirror.local/library/nginx:1.27",
+		},
+		{
+			name:      "Docker Hub image without override",
+			image:     "nginx",
+			overrides: map[string]string{"gcr.io": "mirror.local"},
+			expected:  "nginx",
+		},
+		{
+			name:      "Image with digest",
+			image:     "myapp/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			overrides: map[string]string{"myapp": "should-not-be-replaced"},
+			expected:  "myapp/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			name:  "Repository prefix",
+			image: "gcr.io/project-a/app/image:v1",
+			overrides: map[string]string{
+				"gcr.io/project-a": "mirror.local/a",
+				"gcr.io/project-b": "other.mirror/b",
+			},
+			expected: "mirror.local/a/app/image:v1",
+		},
+		{
+			name:  "Most specific prefix wins",
+			image: "gcr.io/project-a/app/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			overrides: map[string]string{
+				"gcr.io":               "mirror.local",
+				"gcr.io/project-a":     "mirror.local/a",
+				"gcr.io/project-a/app": "mirror.local/a-app",
+			},
+			expected: "mirror.local/a-app/image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			name:  "Registry override applies to other repositories",
+			image: "gcr.io/project-c/image:v1",
+			overrides: map[string]string{
+				"gcr.io":           "mirror.local",
+				"gcr.io/project-a": "mirror.local/a",
+			},
+			expected: "mirror.local/project-c/image:v1",
+		},
+		{
+			name:      "Prefix matches whole path segments only",
+			image:     "gcr.io/project-ab/image:v1",
+			overrides: map[string]string{"gcr.io/project-a": "mirror.local/a"},
+			expected:  "gcr.io/project-ab/image:v1",
+		},
+		{
+			name:      "Prefix matching the whole repository",
+			image:     "gcr.io/project-a/image:v1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			overrides: map[string]string{"gcr.io/project-a/image": "mirror.local/image"},
+			expected:  "mirror.local/image:v1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+		{
+			name:      "Docker Hub repository prefix",
+			image:     "bitnami/redis",
+			overrides: map[string]string{"index.docker.io/bitnami": "mirror.local/bitnami-mirror"},
+			expected:  "mirror.local/bitnami-mirror/redis:latest",
+		},
+		{
+			name:      "Docker Hub official images prefix",
+			image:     "nginx:1.27",
+			overrides: map[string]string{"docker.io/library": "mirror.local/official"},
+			expected:  "mirror.local/official/nginx:1.27",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := k8s.NewImageRewriter(tt.overrides, nil).Rewrite(tt.image)
+			if err != nil {
+				t.Fatalf("Rewrite(%q) with %q failed: %s", tt.image, tt.overrides, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Rewrite(%q) with %q = %q; want %q", tt.image, tt.overrides, result, tt.expected)
+			}
+		})
+	}
+}
@@ -2,31 +2,151 @@ package k8s
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/distribution/reference"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func fixRegistry(registry string, overrides map[string]string) string {
-	override, found := overrides[registry]
-	if !found {
-		return registry
+// DefaultRegistry - the registry of the images without an explicit registry
+const DefaultRegistry = "docker.io"
+
+// dockerHubOfficialRepository - the repository of the Docker Hub images
+// without a namespace, e.g. 'nginx'
+const dockerHubOfficialRepository = "library"
+
+// dockerHubRegistries - the names of the Docker Hub registry
+var dockerHubRegistries = []string{
+	DefaultRegistry,
+	"index.docker.io",
+	"registry-1.docker.io",
+	"registry.hub.docker.com",
+}
+
+// NormalizeRegistry - returns the default registry for all the names of the
+// Docker Hub registry, other registries are returned unchanged
+func NormalizeRegistry(registry string) string {
+	if slices.Contains(dockerHubRegistries, registry) {
+		return DefaultRegistry
+	}
+	return registry
+}
+
+// ImageName - the image parsed according to the distribution reference
+// grammar; the repository is the form the overrides and the rewrite rules are
+// matched against
+type ImageName struct {
+	Image string
+	// Registry - the normalized registry, e.g. 'docker.io' for 'nginx'
+	Registry string
+	// Repository - the repository path without the registry, fully qualified
+	// for Docker Hub, e.g. 'library/nginx' for 'nginx'
+	Repository string
+	// Suffix - the tag and the digest, e.g. ':v1@sha256:...'; the Docker Hub
+	// images without both are tagged as 'latest'
+	Suffix string
+}
+
+// ParseImage - parses the image reference, the malformed references are
+// reported with the reason, e.g. an upper case repository name
+func ParseImage(image string) (ImageName, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ImageName{}, fmt.Errorf("invalid image reference '%s': %w", image, err)
+	}
+
+	registry := NormalizeRegistry(reference.Domain(named))
+	repository := reference.Path(named)
+
+	if registry == DefaultRegistry {
+		// the Docker Hub shorthands are resolved by Docker Hub only, so they
+		// are expanded, e.g. 'nginx' to 'library/nginx:latest'
+		if !strings.Contains(repository, "/") {
+			repository = dockerHubOfficialRepository + "/" + repository
+		}
+		named = reference.TagNameOnly(named)
+	}
+
+	var suffix string
+	if tagged, ok := named.(reference.Tagged); ok {
+		suffix = ":" + tagged.Tag()
 	}
-	return override
+	if digested, ok := named.(reference.Digested); ok {
+		suffix += "@" + digested.Digest().String()
+	}
+
+	return ImageName{
+		Image:      image,
+		Registry:   registry,
+		Repository: repository,
+		Suffix:     suffix,
+	}, nil
 }
 
-func AlterPodImageRegistry(image string, overrides map[string]string) string {
-	data := strings.Split(image, "/")
+// ImageRegistry - returns the registry the image is pulled from
+func ImageRegistry(image string) (string, error) {
+	name, err := ParseImage(image)
+	if err != nil {
+		return "", err
+	}
+	return name.Registry, nil
+}
 
-	isRegistryProvided := len(data) > 1 &&
-		(strings.Contains(data[0], ".") || strings.Contains(data[0], ":"))
+// matchRepositoryPrefix - returns the part of the repository path following
+// the prefix in the host[:port][/path] format; the prefixes match whole path
+// segments only and the Docker Hub prefixes can use any of its names
+func matchRepositoryPrefix(prefix, registry, repository string) (string, bool) {
+	prefixRegistry, prefixPath, _ := strings.Cut(prefix, "/")
+	if NormalizeRegistry(prefixRegistry) != registry {
+		return "", false
+	}
 
-	if isRegistryProvided {
-		registry := fixRegistry(data[0], overrides)
-		return fmt.Sprintf("%s/%s", registry, strings.Join(data[1:], "/"))
+	switch {
+	case prefixPath == "":
+		return "/" + repository, true
+	case repository == prefixPath:
+		return "", true
+	case strings.HasPrefix(repository, prefixPath+"/"):
+		return repository[len(prefixPath):], true
+	default:
+		return "", false
 	}
+}
+
+// HasPrefix - returns true if the image is pulled from the registry or the
+// repository prefix in the host[:port][/path] format
+func (n ImageName) HasPrefix(prefix string) bool {
+	_, found := matchRepositoryPrefix(prefix, n.Registry, n.Repository)
+	return found
+}
 
-	return image
+// lookupOverride - returns the key of the override with the longest
+// repository prefix matching the image and the part of the repository path
+// following the prefix
+func lookupOverride(registry, repository string, overrides map[string]string) (string, string, bool) {
+	var key, rest string
+	longest := -1
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	// sort the keys to resolve the ties between the Docker Hub names
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		remaining, found := matchRepositoryPrefix(k, registry, repository)
+		if !found {
+			continue
+		}
+
+		// the registry of all matching prefixes is the same
+		if _, prefixPath, _ := strings.Cut(k, "/"); len(prefixPath) > longest {
+			key, rest, longest = k, remaining, len(prefixPath)
+		}
+	}
+	return key, rest, longest != -1
 }
 
 // Contains - returns true if l contains all the keys with values from r
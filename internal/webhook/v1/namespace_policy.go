@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// GetPolicy - returns the bootstrap policy of the namespace, nil if the
+// namespace has none
+type GetPolicy = func(context.Context, string) (*rtbootstrapperv1alpha1.NamespaceBootstrapPolicy, error)
+
+// Namespace - the namespace of the pod together with the settings of its
+// bootstrap policy
+type Namespace struct {
+	*corev1.Namespace
+	Settings TenantSettings
+}
+
+// TenantSettings - the settings of the namespace bootstrap policy that are
+// allowed by the tenant policy of the configuration
+type TenantSettings struct {
+	// Features - the features enabled ("true") or disabled ("false") by the policy
+	Features map[string]string
+	// ClusterTrustBundle - the trust bundle mounted instead of the default one
+	ClusterTrustBundle *k8s.ClusterTrustBundle
+	// Env - environment variables added to all containers
+	Env []corev1.EnvVar
+}
+
+// tenantSettings - drops the settings of the policy that exceed the limits of
+// the tenant policy; the dropped settings are logged
+func tenantSettings(
+	policy *rtbootstrapperv1alpha1.NamespaceBootstrapPolicy,
+	limits *apiv1.TenantPolicy,
+	logger *slog.Logger) TenantSettings {
+
+	var result TenantSettings
+	if policy == nil || limits == nil {
+		return result
+	}
+
+	logger = logger.With("policy-namespace", policy.Namespace, "policy-name", policy.Name)
+
+	result.Features = make(map[string]string, len(policy.Spec.Features))
+	for feature, enabled := range policy.Spec.Features {
+		if !limits.AllowsFeature(feature) {
+			logger.Warn("feature not allowed by the tenant policy, ignoring", "feature", feature)
+			continue
+		}
+		result.Features[feature] = strconv.FormatBool(enabled)
+	}
+
+	if name := policy.Spec.ClusterTrustBundle; name != "" {
+		ctb, found := limits.ClusterTrustBundle(name)
+		if found {
+			result.ClusterTrustBundle = &ctb
+		} else {
+			logger.Warn("cluster trust bundle not allowed by the tenant policy, ignoring",
+				"cluster-trust-bundle", name)
+		}
+	}
+
+	for _, env := range policy.Spec.Env {
+		if !limits.AllowsEnvVar(env.Name) {
+			logger.Warn("env variable not allowed by the tenant policy, ignoring", "env", env.Name)
+			continue
+		}
+		result.Env = append(result.Env, corev1.EnvVar{
+			Name:  env.Name,
+			Value: env.Value,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_tenantSettings(t *testing.T) {
+	allowedCTB := k8s.ClusterTrustBundle{
+		Name:            "tenant-ctb",
+		CertWritePath:   "ca.pem",
+		VolumeMountPath: "/etc/ssl/certs",
+		VolumeName:      "tenant-certs",
+	}
+
+	limits := &apiv1.TenantPolicy{
+		AllowedFeatures:     []string{apiv1.AnnotationSetFipsMode},
+		ClusterTrustBundles: []k8s.ClusterTrustBundle{allowedCTB},
+		AllowedEnvVars:      []string{"TENANT_*"},
+	}
+
+	tcs := []struct {
+		name     string
+		spec     rtbootstrapperv1alpha1.NamespaceBootstrapPolicySpec
+		limits   *apiv1.TenantPolicy
+		expected TenantSettings
+	}{
+		{
+			name: "settings within limits",
+			spec: rtbootstrapperv1alpha1.NamespaceBootstrapPolicySpec{
+				Features:           map[string]bool{apiv1.AnnotationSetFipsMode: false},
+				ClusterTrustBundle: "tenant-ctb",
+				Env:                []rtbootstrapperv1alpha1.EnvVar{{Name: "TENANT_ID", Value: "42"}},
+			},
+			limits: limits,
+			expected: TenantSettings{
+				Features:           map[string]string{apiv1.AnnotationSetFipsMode: "false"},
+				ClusterTrustBundle: &allowedCTB,
+				Env:                []corev1.EnvVar{{Name: "TENANT_ID", Value: "42"}},
+			},
+		},
+		{
+			name: "settings exceeding limits are dropped",
+			spec: rtbootstrapperv1alpha1.NamespaceBootstrapPolicySpec{
+				Features:           map[string]bool{apiv1.AnnotationAlterImgRegistry: false},
+				ClusterTrustBundle: "other-ctb",
+				Env: []rtbootstrapperv1alpha1.EnvVar{
+					{Name: "OTHER", Value: "x"},
+					{Name: apiv1.EnvKymaFipsModeEnabled, Value: "false"},
+				},
+			},
+			limits: &apiv1.TenantPolicy{
+				AllowedFeatures: []string{apiv1.AnnotationSetFipsMode},
+				AllowedEnvVars:  []string{"*"},
+			},
+			expected: TenantSettings{
+				Features: map[string]string{},
+				Env:      []corev1.EnvVar{{Name: "OTHER", Value: "x"}},
+			},
+		},
+		{
+			name: "policies ignored without limits",
+			spec: rtbootstrapperv1alpha1.NamespaceBootstrapPolicySpec{
+				Features: map[string]bool{apiv1.AnnotationSetFipsMode: true},
+			},
+			expected: TenantSettings{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := rtbootstrapperv1alpha1.NamespaceBootstrapPolicy{Spec: tc.spec}
+			actual := tenantSettings(&policy, tc.limits, slog.Default())
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_BuildDefaulterTenantEnv(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers: []corev1.Container{{
+				Name: "main",
+				Env:  []corev1.EnvVar{{Name: "TENANT_ID", Value: "own"}},
+			}},
+		},
+	}
+
+	ns := Namespace{
+		Namespace: &corev1.Namespace{},
+		Settings: TenantSettings{
+			Env: []corev1.EnvVar{{Name: "TENANT_ID", Value: "42"}},
+		},
+	}
+
+	defaulter := BuildDefaulterTenantEnv()
+
+	modified, err := defaulter(context.Background(), &pod, &ns)
+	assert.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, []corev1.EnvVar{{Name: "TENANT_ID", Value: "42"}}, pod.Spec.InitContainers[0].Env)
+	// the variables defined by the container are kept
+	assert.Equal(t, []corev1.EnvVar{{Name: "TENANT_ID", Value: "own"}}, pod.Spec.Containers[0].Env)
+
+	modified, err = defaulter(context.Background(), &pod, &ns)
+	assert.NoError(t, err)
+	assert.False(t, modified)
+}
@@ -9,15 +9,12 @@ import (
 )
 
 var (
-	annotationSetFipsMode = map[string]string{
-		apiv1.AnnotationSetFipsMode: "true"}
-
 	envVarKymaFipsModeEnabled = corev1.EnvVar{
 		Name:  apiv1.EnvKymaFipsModeEnabled,
 		Value: "true"}
 )
 
-func BuildDefaulterFipsMode(nsf apiv1.NamespaceFeatures) PodDefaulter {
+func BuildDefaulterFipsMode(nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
 	handleContainers := func(cs []corev1.Container) bool {
 		var modified bool
 		for i, c := range cs {
@@ -64,7 +61,7 @@ func BuildDefaulterFipsMode(nsf apiv1.NamespaceFeatures) PodDefaulter {
 	}
 
 	return defaultPod(setFipsMode, updateOpts{
-		activeAnnotations: annotationSetFipsMode,
+		feature:           apiv1.AnnotationSetFipsMode,
 		namespaceFeatures: nsf,
 	})
 }
@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BuildDefaulterTenantEnv - adds the env variables of the namespace bootstrap
+// policy to all containers; variables already defined by a container are kept
+func BuildDefaulterTenantEnv() PodDefaulter {
+	handleContainers := func(cs []corev1.Container, envs []corev1.EnvVar) bool {
+		var modified bool
+		for i := range cs {
+			for _, env := range envs {
+				if slices.ContainsFunc(cs[i].Env, func(v corev1.EnvVar) bool {
+					return v.Name == env.Name
+				}) {
+					slog.Debug("env variable already exists", "name", env.Name)
+					continue
+				}
+
+				cs[i].Env = append(cs[i].Env, env)
+				modified = true
+				slog.Debug("env variable added", "name", env.Name)
+			}
+		}
+		return modified
+	}
+
+	return func(_ context.Context, p *corev1.Pod, ns *Namespace) (bool, error) {
+		var modified bool
+		for _, cs := range [][]corev1.Container{
+			p.Spec.InitContainers,
+			p.Spec.Containers,
+		} {
+			if handleContainers(cs, ns.Settings.Env) {
+				modified = true
+			}
+		}
+		return modified, nil
+	}
+}
@@ -1,122 +1,780 @@
 package v1
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"reflect"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/kyma-project/rt-bootstrapper/internal/registry"
 	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
 	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
-type PodDefaulter = func(p *corev1.Pod, nsAnnotations map[string]string) (bool, error)
+type PodDefaulter = func(ctx context.Context, p *corev1.Pod, ns *Namespace) (bool, error)
 
-var (
-	annotationsAlterImgRegistry = map[string]string{
-		apiv1.AnnotationAlterImgRegistry: "true",
-	}
-	annotationsSetPullSecret = map[string]string{
-		apiv1.AnnotationSetPullSecret: "true",
-	}
-	annotationAddClusterTrustBundle = map[string]string{
-		apiv1.AnnotationAddClusterTrustBundle: "true",
-	}
+type updateOpts struct {
+	feature           string
+	namespaceFeatures *apiv1.NamespaceFeatureMatcher
+}
+
+const (
+	featureSourcePod       = "pod"
+	featureSourceNamespace = "namespace"
+	featureSourcePolicy    = "policy"
+	featureSourceDefault   = "default"
+	featureSourceNone      = "none"
 )
 
-type updateOpts struct {
-	activeAnnotations map[string]string
-	namespaceFeatures apiv1.NamespaceFeatures
+type featureLevel struct {
+	source string
+	values map[string]string
+}
+
+// resolveFeature - returns if the feature is enabled and the level the
+// decision was taken on; the first level with a valid value takes precedence
+func resolveFeature(feature string, logger *slog.Logger, levels ...featureLevel) (bool, string) {
+	for _, level := range levels {
+		value, found := level.values[feature]
+		if !found {
+			continue
+		}
+
+		switch value {
+		case "true":
+			return true, level.source
+		case "false":
+			return false, level.source
+		default:
+			logger.Warn("invalid feature value, ignoring",
+				"source", level.source,
+				"value", value)
+		}
+	}
+	return false, featureSourceNone
 }
 
+// defaultPod - applies the update if the feature is enabled for the pod, using
+// the following precedence: pod annotation, namespace annotation, namespace
+// bootstrap policy, default configuration
 func defaultPod(update func(*corev1.Pod) bool, opts updateOpts) PodDefaulter {
-	return func(p *corev1.Pod, nsAnnotations map[string]string) (bool, error) {
+	return defaultPodOrFail(func(_ context.Context, p *corev1.Pod) (bool, error) {
+		return update(p), nil
+	}, opts)
+}
+
+// defaultPodOrFail - like defaultPod, but the update can reject the pod
+func defaultPodOrFail(update func(context.Context, *corev1.Pod) (bool, error), opts updateOpts) PodDefaulter {
+	return func(ctx context.Context, p *corev1.Pod, ns *Namespace) (bool, error) {
 		// prepare logger
 		kvs := keysAndValues(p)
 
-		defaultFeatures := opts.namespaceFeatures.Features(p.Namespace)
+		nsAnnotations := ns.Annotations
+		defaultFeatures := opts.namespaceFeatures.Features(ns.Namespace)
 
 		logger := slog.Default().
 			WithGroup("args").
 			With(kvs...).
 			With("ns-annotations", nsAnnotations).
-			With("active-features", opts.activeAnnotations).
+			With("feature", opts.feature).
 			With("default-features", defaultFeatures)
 
-		for _, annotations := range []map[string]string{defaultFeatures, nsAnnotations, p.Annotations} {
-			if k8s.Contains(annotations, opts.activeAnnotations) {
-				logger.Debug("pod defaulting opt in")
-				return update(p), nil
+		enabled, source := resolveFeature(opts.feature, logger,
+			featureLevel{source: featureSourcePod, values: p.Annotations},
+			featureLevel{source: featureSourceNamespace, values: nsAnnotations},
+			featureLevel{source: featureSourcePolicy, values: ns.Settings.Features},
+			featureLevel{source: featureSourceDefault, values: defaultFeatures},
+		)
+
+		if !enabled {
+			logger.Debug("pod defaulting opt out", "source", source)
+			return false, nil
+		}
+
+		logger.Debug("pod defaulting opt in", "source", source)
+		return update(ctx, p)
+	}
+}
+
+// containerList - the containers of one kind with their field path
+type containerList struct {
+	fldPath    *field.Path
+	containers []corev1.Container
+}
+
+// podContainers - returns the init containers and the containers of the pod
+func podContainers(p *corev1.Pod) []containerList {
+	specPath := field.NewPath("spec")
+	return []containerList{
+		{fldPath: specPath.Child("initContainers"), containers: p.Spec.InitContainers},
+		{fldPath: specPath.Child("containers"), containers: p.Spec.Containers},
+	}
+}
+
+// MirrorCheck - verifies the rewritten images exist in the mirror
+type MirrorCheck struct {
+	Checker *registry.ManifestChecker
+	// Reject - the pods with images missing in the mirror are rejected
+	// instead of keeping the original images
+	Reject bool
+	// Timeout - the timeout of a single check, limited by the deadline of the
+	// admission request
+	Timeout time.Duration
+}
+
+// missing - returns the reason the image can not be used, empty if it exists
+// in the mirror
+func (m *MirrorCheck) missing(ctx context.Context, image string) string {
+	ctx, cancel := context.WithTimeout(ctx, m.Timeout)
+	defer cancel()
+
+	exists, err := m.Checker.Exists(ctx, image)
+	if err != nil {
+		return fmt.Sprintf("unable to check image '%s' in the mirror: %s", image, err)
+	}
+	if !exists {
+		return fmt.Sprintf("image '%s' not found in the mirror", image)
+	}
+	return ""
+}
+
+// missingAll - checks the images concurrently and returns the reasons they
+// can not be used by their index, all empty if the check is disabled
+func (m *MirrorCheck) missingAll(ctx context.Context, images []string) []string {
+	result := make([]string, len(images))
+	if m == nil {
+		return result
+	}
+
+	concurrently(len(images), func(i int) {
+		result[i] = m.missing(ctx, images[i])
+	})
+	return result
+}
+
+// concurrently - calls f for all the indices lower than n concurrently and
+// waits for the calls to return
+func concurrently(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			f(i)
+		})
+	}
+	wg.Wait()
+}
+
+type AlterImgRegistryOpts struct {
+	Rewriter *k8s.ImageRewriter
+	// SkipInvalid - the malformed image references are left unchanged
+	// instead of rejecting the pod
+	SkipInvalid bool
+	// MirrorCheck - verifies the rewritten images, optional
+	MirrorCheck *MirrorCheck
+	// Exclusions - the images never rewritten, optional
+	Exclusions *apiv1.ImageExclusionMatcher
+
+	// namespaceFeatures - the default features of the pod namespace the
+	// named exclusions are enabled by
+	namespaceFeatures map[string]string
+}
+
+// imageRef - an image reference of the pod altered by the registry rewrite
+type imageRef struct {
+	fldPath *field.Path
+	// key - the key the original image is recorded by, i.e. the name of the
+	// container or the volume or the annotation key
+	key   string
+	image string
+	set   func(string)
+	// rewritten - the rewritten images recorded by the earlier invocations
+	rewritten map[string]string
+	// originals - the original images recorded by this invocation
+	originals map[string]string
+	// results - the rewritten images recorded by this invocation
+	results map[string]string
+	logger  *slog.Logger
+}
+
+// rewriteImage - returns the rewrite of the image, the image itself is the
+// result if it is left unchanged
+func rewriteImage(ref imageRef, opts AlterImgRegistryOpts) (k8s.ImageRewrite, *field.Error) {
+	unchanged := k8s.ImageRewrite{Result: ref.image}
+
+	// the malformed references are reported by the rewriter
+	if name, err := k8s.ParseImage(ref.image); err == nil {
+		if exclusion := opts.Exclusions.Excluded(opts.namespaceFeatures, name); exclusion != "" {
+			ref.logger.Debug("image excluded from the registry rewrite", "exclusion", exclusion)
+			return unchanged, nil
+		}
+	}
+
+	rewrite, err := opts.Rewriter.Explain(ref.image)
+	if err != nil && opts.SkipInvalid {
+		ref.logger.Warn("unable to alter image, skipping", "error", err)
+		return unchanged, nil
+	}
+	if err != nil {
+		return unchanged, field.Invalid(ref.fldPath, ref.image, err.Error())
+	}
+	return rewrite, nil
+}
+
+// rewrittenBefore - returns true if the image was rewritten by an earlier
+// invocation of the webhook, i.e. it still equals the recorded result; the
+// webhook is invoked again if a later webhook changes the pod, and the images
+// the later webhook replaced are rewritten again
+func rewrittenBefore(rewritten map[string]string, key, image string) bool {
+	result, found := rewritten[key]
+	return found && result == image
+}
+
+// alterImages - rewrites the image references and records the original ones;
+// the references rewritten before are skipped. The rewritten images are
+// checked in the mirror concurrently, so the checks of all the images of the
+// pod fit in the deadline of the admission request.
+func alterImages(ctx context.Context, refs []imageRef, opts AlterImgRegistryOpts) field.ErrorList {
+	var errs field.ErrorList
+	var pending []imageRef
+	var rewrites []k8s.ImageRewrite
+	for _, ref := range refs {
+		if rewrittenBefore(ref.rewritten, ref.key, ref.image) {
+			ref.logger.Debug("image already altered")
+			continue
+		}
+
+		rewrite, err := rewriteImage(ref, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if rewrite.Result == ref.image {
+			continue
+		}
+
+		pending = append(pending, ref)
+		rewrites = append(rewrites, rewrite)
+	}
+
+	images := make([]string, len(rewrites))
+	for i, rewrite := range rewrites {
+		images[i] = rewrite.Result
+	}
+	reasons := opts.MirrorCheck.missingAll(ctx, images)
+
+	for i, ref := range pending {
+		if reason := reasons[i]; reason != "" {
+			if opts.MirrorCheck.Reject {
+				errs = append(errs, field.Invalid(ref.fldPath, ref.image, reason))
+				continue
 			}
+
+			ref.logger.Warn("altered image not available, keeping the original image", "reason", reason)
+			continue
 		}
 
-		logger.Debug("opt out", "ns-annotations", nsAnnotations)
-		return false, nil
+		ref.logger.Debug("image altered",
+			"rule", rewrites[i].Rule,
+			"altered-image-name", rewrites[i].Result)
+
+		ref.originals[ref.key] = ref.image
+		ref.results[ref.key] = rewrites[i].Result
+		ref.set(rewrites[i].Result)
 	}
+	return errs
 }
 
-func alterImgRegistry(containers []corev1.Container, overrides map[string]string) bool {
-	var modified bool
+// containerImageRefs - returns the images of the containers, the originals
+// and the results are recorded by the container name
+func containerImageRefs(
+	fldPath *field.Path,
+	containers []corev1.Container,
+	rewritten map[string]string,
+	originals map[string]string,
+	results map[string]string) []imageRef {
+
+	var result []imageRef
 	for i := range containers {
-		alteredImage := k8s.AlterPodImageRegistry(
-			containers[i].Image,
-			overrides)
+		result = append(result, imageRef{
+			fldPath: fldPath.Index(i).Child("image"),
+			key:     containers[i].Name,
+			image:   containers[i].Image,
+			set: func(image string) {
+				containers[i].Image = image
+			},
+			rewritten: rewritten,
+			originals: originals,
+			results:   results,
+			logger: slog.With("image-name", containers[i].Image,
+				"container-name", containers[i].Name),
+		})
+	}
+	return result
+}
 
-		if alteredImage == containers[i].Image {
+// volumeImageRefs - returns the references of the image volumes, the
+// originals and the results are recorded by the volume name
+func volumeImageRefs(
+	fldPath *field.Path,
+	volumes []corev1.Volume,
+	rewritten map[string]string,
+	originals map[string]string,
+	results map[string]string) []imageRef {
+
+	var result []imageRef
+	for i := range volumes {
+		source := volumes[i].Image
+		if source == nil || source.Reference == "" {
 			continue
 		}
 
-		containers[i].Image = alteredImage
-		modified = true
+		result = append(result, imageRef{
+			fldPath: fldPath.Index(i).Child("image", "reference"),
+			key:     volumes[i].Name,
+			image:   source.Reference,
+			set: func(image string) {
+				source.Reference = image
+			},
+			rewritten: rewritten,
+			originals: originals,
+			results:   results,
+			logger: slog.With("image-name", source.Reference,
+				"volume-name", volumes[i].Name),
+		})
+	}
+	return result
+}
+
+// BuildPodDefaulterAlterImgRegistry - rewrites the container images and the
+// references of the image volumes; the pods with malformed image references
+// are rejected unless opts.SkipInvalid is set
+func BuildPodDefaulterAlterImgRegistry(
+	opts AlterImgRegistryOpts,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	alterPodImageRegistry := func(ctx context.Context, p *corev1.Pod, opts AlterImgRegistryOpts) (bool, error) {
+		// the annotations can be malformed only if they were set by someone
+		// else
+		rewritten, _ := apiv1.RewrittenImages(p)
+		rewrittenVolumes, _ := apiv1.RewrittenVolumeImages(p)
+
+		var refs []imageRef
+		originals := map[string]string{}
+		results := map[string]string{}
+		for _, containers := range podContainers(p) {
+			refs = append(refs, containerImageRefs(
+				containers.fldPath, containers.containers, rewritten, originals, results)...)
+		}
 
-		slog.With("overrides", overrides,
-			"image-name", containers[i].Image,
-			"container-name", containers[i].Name).Debug("image altered")
+		volumeOriginals := map[string]string{}
+		volumeResults := map[string]string{}
+		refs = append(refs, volumeImageRefs(field.NewPath("spec", "volumes"),
+			p.Spec.Volumes, rewrittenVolumes, volumeOriginals, volumeResults)...)
+
+		if errs := alterImages(ctx, refs, opts); len(errs) > 0 {
+			return false, apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), p.Name, errs)
+		}
+
+		apiv1.RecordOriginalImages(p, originals)
+		apiv1.RecordRewrittenImages(p, results)
+		apiv1.RecordOriginalVolumeImages(p, volumeOriginals)
+		apiv1.RecordRewrittenVolumeImages(p, volumeResults)
+		return len(originals) > 0 || len(volumeOriginals) > 0, nil
 	}
-	return modified
+
+	return alterImgRegistryInNamespace(alterPodImageRegistry, opts, nsf)
 }
 
-func BuildPodDefaulterAlterImgRegistry(overrides map[string]string, nsf apiv1.NamespaceFeatures) PodDefaulter {
-	alterPodImageRegistry := func(p *corev1.Pod) bool {
-		var modified bool
-		for _, containers := range [][]corev1.Container{
-			p.Spec.InitContainers,
-			p.Spec.Containers,
-		} {
-			if !alterImgRegistry(containers, overrides) {
+// BuildPodDefaulterAlterAnnotationImgRegistry - rewrites the images of the
+// given pod annotations read by the webhooks running later, e.g. the proxy
+// image of the Istio sidecar injector; the annotations rewritten before are
+// skipped
+func BuildPodDefaulterAlterAnnotationImgRegistry(
+	annotations []string,
+	opts AlterImgRegistryOpts,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	alterAnnotationImageRegistry := func(ctx context.Context, p *corev1.Pod, opts AlterImgRegistryOpts) (bool, error) {
+		rewritten, _ := apiv1.RewrittenAnnotations(p)
+		annotationsPath := field.NewPath("metadata", "annotations")
+
+		var refs []imageRef
+		originals := map[string]string{}
+		results := map[string]string{}
+		for _, key := range annotations {
+			image, found := p.Annotations[key]
+			if !found {
 				continue
 			}
-			modified = true
+
+			refs = append(refs, imageRef{
+				fldPath: annotationsPath.Key(key),
+				key:     key,
+				image:   image,
+				set: func(image string) {
+					p.Annotations[key] = image
+				},
+				rewritten: rewritten,
+				originals: originals,
+				results:   results,
+				logger:    slog.With("image-name", image, "annotation", key),
+			})
 		}
-		return modified
+
+		if errs := alterImages(ctx, refs, opts); len(errs) > 0 {
+			return false, apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), p.Name, errs)
+		}
+
+		apiv1.RecordOriginalAnnotations(p, originals)
+		apiv1.RecordRewrittenAnnotations(p, results)
+		return len(originals) > 0, nil
 	}
 
-	return defaultPod(alterPodImageRegistry, updateOpts{
-		activeAnnotations: annotationsAlterImgRegistry,
+	return alterImgRegistryInNamespace(alterAnnotationImageRegistry, opts, nsf)
+}
+
+// alterImgRegistryInNamespace - applies the registry rewrite with the
+// exclusions enabled by the default features of the pod namespace
+func alterImgRegistryInNamespace(
+	alter func(context.Context, *corev1.Pod, AlterImgRegistryOpts) (bool, error),
+	opts AlterImgRegistryOpts,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	update := updateOpts{
+		feature:           apiv1.AnnotationAlterImgRegistry,
 		namespaceFeatures: nsf,
+	}
+
+	return func(ctx context.Context, p *corev1.Pod, ns *Namespace) (bool, error) {
+		opts := opts
+		opts.namespaceFeatures = nsf.Features(ns.Namespace)
+
+		return defaultPodOrFail(func(ctx context.Context, p *corev1.Pod) (bool, error) {
+			return alter(ctx, p, opts)
+		}, update)(ctx, p, ns)
+	}
+}
+
+type PinImgDigestOpts struct {
+	Resolver *registry.ManifestChecker
+	// Reject - the pods with images that can not be resolved are rejected
+	// instead of keeping the tags
+	Reject bool
+	// Timeout - the timeout of a single resolution, limited by the deadline
+	// of the admission request
+	Timeout time.Duration
+}
+
+// pinImgDigest - returns the image pinned to the digest its tag refers to in
+// its registry and the tag
+func pinImgDigest(ctx context.Context, image string, opts PinImgDigestOpts) (string, string, error) {
+	name, err := k8s.ParseImage(image)
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	digest, err := opts.Resolver.Digest(ctx, image)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to resolve digest of image '%s': %w", image, err)
+	}
+
+	// the images without a tag refer to the 'latest' one
+	tag := strings.TrimPrefix(name.Suffix, ":")
+	if tag == "" {
+		tag = "latest"
+	}
+
+	pinned := strings.TrimSuffix(image, name.Suffix) + "@" + digest
+	if _, err := k8s.ParseImage(pinned); err != nil {
+		return "", "", fmt.Errorf("invalid digest of image '%s': %w", image, err)
+	}
+	return pinned, tag, nil
+}
+
+// imgDigestPin - the resolution of the digest of a container image
+type imgDigestPin struct {
+	fldPath   *field.Path
+	container *corev1.Container
+	pinned    string
+	tag       string
+	err       error
+}
+
+// pinImgDigests - the second pass of the registry rewrite; replaces the tags
+// of the container images with the digests and records the tags by the
+// container name; the images with a digest are left unchanged. The digests
+// are resolved concurrently, so the resolutions of all the images of the pod
+// fit in the deadline of the admission request.
+func pinImgDigests(
+	ctx context.Context,
+	lists []containerList,
+	opts PinImgDigestOpts,
+	tags map[string]string) field.ErrorList {
+
+	var pins []imgDigestPin
+	for _, list := range lists {
+		for i := range list.containers {
+			if strings.Contains(list.containers[i].Image, "@") {
+				continue
+			}
+
+			pins = append(pins, imgDigestPin{
+				fldPath:   list.fldPath.Index(i).Child("image"),
+				container: &list.containers[i],
+			})
+		}
+	}
+
+	concurrently(len(pins), func(i int) {
+		pins[i].pinned, pins[i].tag, pins[i].err = pinImgDigest(ctx, pins[i].container.Image, opts)
 	})
+
+	var errs field.ErrorList
+	for _, pin := range pins {
+		logger := slog.With("image-name", pin.container.Image,
+			"container-name", pin.container.Name)
+
+		if pin.err != nil && opts.Reject {
+			errs = append(errs, field.Invalid(pin.fldPath, pin.container.Image, pin.err.Error()))
+			continue
+		}
+		if pin.err != nil {
+			logger.Warn("unable to pin image digest, keeping the tag", "error", pin.err)
+			continue
+		}
+
+		tags[pin.container.Name] = pin.tag
+		pin.container.Image = pin.pinned
+
+		logger.Debug("image digest pinned", "pinned-image-name", pin.pinned)
+	}
+	return errs
 }
 
-func BuildPodDefaulterAddImagePullSecrets(secretName string, nsf apiv1.NamespaceFeatures) PodDefaulter {
-	addImgPullSecret := func(p *corev1.Pod) bool {
-		imgPullSecret := corev1.LocalObjectReference{Name: secretName}
-		if slices.Contains(p.Spec.ImagePullSecrets, imgPullSecret) {
-			slog.Debug("image pull secret already found")
-			return false
+// recordPinnedResults - replaces the recorded results of the registry rewrite
+// with the pinned images, so the webhook invoked again does not rewrite them
+func recordPinnedResults(p *corev1.Pod, tags map[string]string) {
+	rewritten, _ := apiv1.RewrittenImages(p)
+
+	results := map[string]string{}
+	for _, containers := range podContainers(p) {
+		for _, c := range containers.containers {
+			_, pinned := tags[c.Name]
+			if _, found := rewritten[c.Name]; found && pinned {
+				results[c.Name] = c.Image
+			}
 		}
+	}
+	apiv1.RecordRewrittenImages(p, results)
+}
+
+// BuildPodDefaulterPinImgDigest - pins the container images to the digests
+// resolved from their registries; it runs after the registry rewrite, so the
+// digests are resolved from the mirrors
+func BuildPodDefaulterPinImgDigest(
+	opts PinImgDigestOpts,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	pinPodImgDigests := func(ctx context.Context, p *corev1.Pod) (bool, error) {
+		tags := map[string]string{}
+		if errs := pinImgDigests(ctx, podContainers(p), opts, tags); len(errs) > 0 {
+			return false, apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), p.Name, errs)
+		}
+
+		apiv1.RecordOriginalTags(p, tags)
+		recordPinnedResults(p, tags)
+		return len(tags) > 0, nil
+	}
+
+	return defaultPodOrFail(pinPodImgDigests, updateOpts{
+		feature:           apiv1.AnnotationPinImgDigest,
+		namespaceFeatures: nsf,
+	})
+}
+
+// setImgPullPolicies - sets the pull policies of the containers matched by
+// the rules and records the original ones by the container name
+func setImgPullPolicies(containers []corev1.Container, rules apiv1.ImagePullPolicyRules, originals map[string]string) {
+	for i := range containers {
+		logger := slog.With("image-name", containers[i].Image,
+			"container-name", containers[i].Name)
+
+		name, err := k8s.ParseImage(containers[i].Image)
+		if err != nil {
+			// the malformed references are handled by the registry rewrite
+			continue
+		}
+
+		policy, rule := rules.PullPolicy(name)
+		if rule == "" || policy == containers[i].ImagePullPolicy {
+			continue
+		}
+
+		originals[containers[i].Name] = string(containers[i].ImagePullPolicy)
+		containers[i].ImagePullPolicy = policy
+
+		logger.Debug("image pull policy set",
+			"rule", rule,
+			"pull-policy", policy)
+	}
+}
+
+// BuildPodDefaulterSetImgPullPolicy - sets the pull policies of the
+// containers by the final images; it runs after the registry rewrite and the
+// digest pinning
+func BuildPodDefaulterSetImgPullPolicy(
+	rules apiv1.ImagePullPolicyRules,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	setPodImgPullPolicies := func(p *corev1.Pod) bool {
+		originals := map[string]string{}
+		for _, containers := range podContainers(p) {
+			setImgPullPolicies(containers.containers, rules, originals)
+		}
+
+		apiv1.RecordOriginalPullPolicies(p, originals)
+		return len(originals) > 0
+	}
+
+	return defaultPod(setPodImgPullPolicies, updateOpts{
+		feature:           apiv1.AnnotationSetImgPullPolicy,
+		namespaceFeatures: nsf,
+	})
+}
 
-		slog.Debug("adding new image pull secret")
-		p.Spec.ImagePullSecrets = append(p.Spec.ImagePullSecrets, imgPullSecret)
+// podImages - returns the images of the containers and the references of the
+// image volumes
+func podImages(p *corev1.Pod) []string {
+	var result []string
+	for _, containers := range [][]corev1.Container{
+		p.Spec.InitContainers,
+		p.Spec.Containers,
+	} {
+		for _, c := range containers {
+			result = append(result, c.Image)
+		}
+	}
+	for _, v := range p.Spec.Volumes {
+		if v.Image != nil && v.Image.Reference != "" {
+			result = append(result, v.Image.Reference)
+		}
+	}
+	return result
+}
+
+// podImageRegistries - returns the registries of all the container images and
+// the image volumes
+func podImageRegistries(p *corev1.Pod) []string {
+	var result []string
+	for _, image := range podImages(p) {
+		registry, err := k8s.ImageRegistry(image)
+		if err != nil {
+			// the malformed references are handled by the registry rewrite
+			continue
+		}
+		if slices.Contains(result, registry) {
+			continue
+		}
+		result = append(result, registry)
+	}
+	return result
+}
+
+// servesRegistry - returns true if the secret holds credentials for the
+// registry; the registry has to be both configured for the secret (if any are
+// configured) and found in its '.dockerconfigjson'. The secrets not observed
+// yet serve all the configured registries, the invalid or deleted ones serve
+// none.
+func servesRegistry(secret apiv1.ImagePullSecret, creds *credentials.Store, registry string) bool {
+	if !secret.ServesRegistry(registry) {
+		return false
+	}
+
+	known, found := creds.Registries(secret.Name)
+	if !found {
 		return true
 	}
+	return slices.Contains(known, registry)
+}
+
+// BuildPodDefaulterAddImagePullSecrets - adds the pull secrets serving the
+// registries of the pod images; it has to run after the images are rewritten
+func BuildPodDefaulterAddImagePullSecrets(
+	secrets []apiv1.ImagePullSecret,
+	creds *credentials.Store,
+	nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+
+	addImgPullSecrets := func(p *corev1.Pod) bool {
+		registries := podImageRegistries(p)
+
+		var modified bool
+		for _, secret := range secrets {
+			logger := slog.With("secret-name", secret.Name, "registries", registries)
+
+			if !slices.ContainsFunc(registries, func(registry string) bool {
+				return servesRegistry(secret, creds, registry)
+			}) {
+				logger.Debug("image pull secret does not serve any image registry, skipping")
+				continue
+			}
+
+			imgPullSecret := corev1.LocalObjectReference{Name: secret.Name}
+			if slices.Contains(p.Spec.ImagePullSecrets, imgPullSecret) {
+				logger.Debug("image pull secret already found")
+				continue
+			}
+
+			logger.Debug("adding new image pull secret")
+			p.Spec.ImagePullSecrets = append(p.Spec.ImagePullSecrets, imgPullSecret)
+			modified = true
+		}
+		return modified
+	}
 
-	return defaultPod(addImgPullSecret, updateOpts{
-		activeAnnotations: annotationsSetPullSecret,
+	return defaultPod(addImgPullSecrets, updateOpts{
+		feature:           apiv1.AnnotationSetPullSecret,
 		namespaceFeatures: nsf,
 	})
 }
 
-func BuildDefaulterAddClusterTrustBundle(mapping k8s.ClusterTrustBundle, nsf apiv1.NamespaceFeatures) PodDefaulter {
+// BuildDefaulterAddClusterTrustBundle - mounts the trust bundle selected by the
+// namespace bootstrap policy or, if the policy selects none, the default one
+func BuildDefaulterAddClusterTrustBundle(defaultMapping *k8s.ClusterTrustBundle, nsf *apiv1.NamespaceFeatureMatcher) PodDefaulter {
+	opts := updateOpts{
+		feature:           apiv1.AnnotationAddClusterTrustBundle,
+		namespaceFeatures: nsf,
+	}
+
+	return func(ctx context.Context, p *corev1.Pod, ns *Namespace) (bool, error) {
+		mapping := defaultMapping
+		if ns.Settings.ClusterTrustBundle != nil {
+			mapping = ns.Settings.ClusterTrustBundle
+		}
+
+		if mapping == nil {
+			slog.Debug("no cluster trust bundle configured, nothing to do")
+			return false, nil
+		}
+
+		addClusterTrustBundle := func(p *corev1.Pod) bool {
+			return handleClusterTrustBundle(p, *mapping)
+		}
+		return defaultPod(addClusterTrustBundle, opts)(ctx, p, ns)
+	}
+}
+
+func handleClusterTrustBundle(p *corev1.Pod, mapping k8s.ClusterTrustBundle) bool {
 	slog.Debug("building volume", mapping.KeysAndValues()...)
 
 	vol := mapping.ClusterTrustedBundle()
@@ -160,31 +818,24 @@ func BuildDefaulterAddClusterTrustBundle(mapping k8s.ClusterTrustBundle, nsf api
 		return modified
 	}
 
-	handleClusterTrustBundle := func(p *corev1.Pod) bool {
-		index := slices.IndexFunc(p.Spec.Volumes, func(v corev1.Volume) bool {
-			return v.Name == mapping.VolumeName
-		})
-
-		if index == -1 {
-			// volume does not exist, add it
-			p.Spec.Volumes = append(p.Spec.Volumes, vol)
-			slog.Debug("volume added")
-			return handleContainers(true, p)
-		}
-
-		if reflect.DeepEqual(p.Spec.Volumes[index], vol) {
-			slog.Debug("volume already added, nothing to do")
-			return handleContainers(false, p)
-		}
-
-		p.Spec.Volumes[index] = vol
-		slog.Debug("volume replaced")
+	index := slices.IndexFunc(p.Spec.Volumes, func(v corev1.Volume) bool {
+		return v.Name == mapping.VolumeName
+	})
 
+	if index == -1 {
+		// volume does not exist, add it
+		p.Spec.Volumes = append(p.Spec.Volumes, vol)
+		slog.Debug("volume added")
 		return handleContainers(true, p)
 	}
 
-	return defaultPod(handleClusterTrustBundle, updateOpts{
-		activeAnnotations: annotationAddClusterTrustBundle,
-		namespaceFeatures: nsf,
-	})
+	if reflect.DeepEqual(p.Spec.Volumes[index], vol) {
+		slog.Debug("volume already added, nothing to do")
+		return handleContainers(false, p)
+	}
+
+	p.Spec.Volumes[index] = vol
+	slog.Debug("volume replaced")
+
+	return handleContainers(true, p)
 }
@@ -0,0 +1,870 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_defaultPod_precedence(t *testing.T) {
+	const feature = apiv1.AnnotationSetFipsMode
+
+	tcs := []struct {
+		name            string
+		defaults        []string
+		nsAnnotations   map[string]string
+		policyFeatures  map[string]string
+		podAnnotations  map[string]string
+		expectedUpdated bool
+	}{
+		{
+			name:            "feature not set",
+			expectedUpdated: false,
+		},
+		{
+			name:            "enabled by default",
+			defaults:        []string{feature},
+			expectedUpdated: true,
+		},
+		{
+			name:            "namespace opts out of default",
+			defaults:        []string{feature},
+			nsAnnotations:   map[string]string{feature: "false"},
+			expectedUpdated: false,
+		},
+		{
+			name:            "pod opts out of default",
+			defaults:        []string{feature},
+			podAnnotations:  map[string]string{feature: "false"},
+			expectedUpdated: false,
+		},
+		{
+			name:            "pod opts in despite namespace opt out",
+			defaults:        []string{feature},
+			nsAnnotations:   map[string]string{feature: "false"},
+			podAnnotations:  map[string]string{feature: "true"},
+			expectedUpdated: true,
+		},
+		{
+			name:            "namespace opts in",
+			nsAnnotations:   map[string]string{feature: "true"},
+			expectedUpdated: true,
+		},
+		{
+			name:            "policy opts out of default",
+			defaults:        []string{feature},
+			policyFeatures:  map[string]string{feature: "false"},
+			expectedUpdated: false,
+		},
+		{
+			name:            "namespace annotation takes precedence over policy",
+			nsAnnotations:   map[string]string{feature: "true"},
+			policyFeatures:  map[string]string{feature: "false"},
+			expectedUpdated: true,
+		},
+		{
+			name:            "invalid pod value falls back to namespace",
+			nsAnnotations:   map[string]string{feature: "true"},
+			podAnnotations:  map[string]string{feature: "yes"},
+			expectedUpdated: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, tc.defaults)
+			require.NoError(t, err)
+
+			defaulter := defaultPod(func(*corev1.Pod) bool { return true }, updateOpts{
+				feature:           feature,
+				namespaceFeatures: nsf,
+			})
+
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tc.podAnnotations}}
+			ns := Namespace{
+				Namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: tc.nsAnnotations}},
+				Settings:  TenantSettings{Features: tc.policyFeatures},
+			}
+
+			updated, err := defaulter(context.Background(), &pod, &ns)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedUpdated, updated)
+		})
+	}
+}
+
+func Test_BuildPodDefaulterAddImagePullSecrets(t *testing.T) {
+	secrets := []apiv1.ImagePullSecret{
+		// credentials not observed yet
+		{Name: "all-registries"},
+		// credentials read from the docker config
+		{Name: "private"},
+		// invalid docker config, never added
+		{Name: "invalid"},
+		{Name: "mirror-a", Registries: []string{"mirror-a.local"}},
+		{Name: "mirror-b", Registries: []string{"mirror-b.local:5000"}},
+		// configured registries the credentials were not found for
+		{Name: "mirror-c", Registries: []string{"mirror-c.local", "stale.local"}},
+		{Name: "mirror-invalid", Registries: []string{"mirror-a.local"}},
+	}
+
+	tcs := []struct {
+		name         string
+		images       []string
+		volumeImages []string
+		existing     []corev1.LocalObjectReference
+		expected     []corev1.LocalObjectReference
+	}{
+		{
+			name:   "only secrets serving the pod registries",
+			images: []string{"mirror-a.local/app:v1", "nginx"},
+			expected: []corev1.LocalObjectReference{
+				{Name: "all-registries"},
+				{Name: "mirror-a"},
+			},
+		},
+		{
+			name:     "existing secrets are kept",
+			images:   []string{"mirror-b.local:5000/app:v1"},
+			existing: []corev1.LocalObjectReference{{Name: "mirror-b"}},
+			expected: []corev1.LocalObjectReference{
+				{Name: "mirror-b"},
+				{Name: "all-registries"},
+			},
+		},
+		{
+			name:   "secret with credentials for the image registry",
+			images: []string{"private.local/app:v1"},
+			expected: []corev1.LocalObjectReference{
+				{Name: "all-registries"},
+				{Name: "private"},
+			},
+		},
+		{
+			name:   "configured registry with credentials",
+			images: []string{"mirror-c.local/app:v1"},
+			expected: []corev1.LocalObjectReference{
+				{Name: "all-registries"},
+				{Name: "mirror-c"},
+			},
+		},
+		{
+			name:   "configured registry without credentials",
+			images: []string{"stale.local/app:v1"},
+			expected: []corev1.LocalObjectReference{
+				{Name: "all-registries"},
+			},
+		},
+		{
+			name:         "secrets serving the image volume registries",
+			images:       []string{"nginx"},
+			volumeImages: []string{"mirror-b.local:5000/models/llm:v1"},
+			expected: []corev1.LocalObjectReference{
+				{Name: "all-registries"},
+				{Name: "mirror-b"},
+			},
+		},
+	}
+
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationSetPullSecret})
+	require.NoError(t, err)
+
+	creds := credentials.NewStore()
+	require.NoError(t, creds.Set("private", []byte(`{"auths": {"https://private.local/v1/": {}}}`)))
+	require.Error(t, creds.Set("invalid", []byte(`not json`)))
+	require.NoError(t, creds.Set("mirror-c", []byte(`{"auths": {"mirror-c.local": {}}}`)))
+	require.Error(t, creds.Set("mirror-invalid", []byte(`not json`)))
+
+	defaulter := BuildPodDefaulterAddImagePullSecrets(secrets, creds, nsf)
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := corev1.Pod{Spec: corev1.PodSpec{ImagePullSecrets: tc.existing}}
+			for _, image := range tc.images {
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Image: image})
+			}
+			for _, image := range tc.volumeImages {
+				pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+					VolumeSource: corev1.VolumeSource{Image: &corev1.ImageVolumeSource{Reference: image}},
+				})
+			}
+
+			_, err := defaulter(context.Background(), &pod, &Namespace{Namespace: &corev1.Namespace{}})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, pod.Spec.ImagePullSecrets)
+		})
+	}
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_invalidImages(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	rewriter := k8s.NewImageRewriter(map[string]string{"gcr.io": "mirror.local"}, nil)
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "gcr.io/Project/init:v1"}},
+				Containers: []corev1.Container{
+					{Name: "app", Image: "gcr.io/project/app:v1"},
+					{Name: "sidecar", Image: "gcr.io/project/sidecar:v1:v2"},
+				},
+			},
+		}
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{Rewriter: rewriter}, nsf)
+
+		pod := newPod()
+		modified, err := defaulter(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.Error(t, err)
+		assert.False(t, modified)
+		assert.True(t, apierrors.IsInvalid(err))
+
+		for _, expected := range []string{
+			`spec.initContainers[0].image: Invalid value: "gcr.io/Project/init:v1"`,
+			`spec.containers[1].image: Invalid value: "gcr.io/project/sidecar:v1:v2"`,
+		} {
+			assert.Contains(t, err.Error(), expected)
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+			Rewriter:    rewriter,
+			SkipInvalid: true,
+		}, nsf)
+
+		pod := newPod()
+		modified, err := defaulter(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.NoError(t, err)
+		assert.True(t, modified)
+		assert.Equal(t, "gcr.io/Project/init:v1", pod.Spec.InitContainers[0].Image)
+		assert.Equal(t, "mirror.local/project/app:v1", pod.Spec.Containers[0].Image)
+		assert.Equal(t, "gcr.io/project/sidecar:v1:v2", pod.Spec.Containers[1].Image)
+
+		originals, err := apiv1.OriginalImages(pod)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"app": "gcr.io/project/app:v1"}, originals)
+	})
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_mirrorCheck(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	// the mirror has the synced image only
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/v2/project/synced/manifests/v1" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "https://")
+
+	creds := credentials.NewStore()
+	require.NoError(t, creds.Set("registry-credentials", []byte(`{"auths": {"`+mirror+`": {"auth": "dXNlcjpwYXNz"}}}`)))
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "synced", Image: "gcr.io/project/synced:v1"},
+					{Name: "missing", Image: "gcr.io/project/missing:v1"},
+				},
+			},
+		}
+	}
+
+	tcs := []struct {
+		name     string
+		cfg      apiv1.MirrorCheck
+		expected []string
+		err      string
+	}{
+		{
+			name:     "keep the original images",
+			expected: []string{mirror + "/project/synced:v1", "gcr.io/project/missing:v1"},
+		},
+		{
+			name: "reject the pod",
+			cfg:  apiv1.MirrorCheck{OnMissing: apiv1.MirrorCheckReject},
+			err: `spec.containers[1].image: Invalid value: "gcr.io/project/missing:v1": image '` +
+				mirror + `/project/missing:v1' not found in the mirror`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+				Rewriter: k8s.NewImageRewriter(map[string]string{"gcr.io": mirror}, nil),
+				MirrorCheck: buildMirrorCheck(&tc.cfg, Dependencies{
+					Credentials: creds,
+					HTTPClient:  srv.Client(),
+				}),
+			}, nsf)
+
+			pod := newPod()
+			_, err := defaulter(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.True(t, apierrors.IsInvalid(err))
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, []string{pod.Spec.Containers[0].Image, pod.Spec.Containers[1].Image})
+
+			originals, err := apiv1.OriginalImages(pod)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"synced": "gcr.io/project/synced:v1"}, originals)
+		})
+	}
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_mirrorCheckDeadline(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	// the mirror responds once all the images of the pod are queried, i.e.
+	// only if they are queried concurrently
+	var requests atomic.Int32
+	var unresponsive atomic.Bool
+	released := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unresponsive.Load() {
+			<-r.Context().Done()
+			return
+		}
+		if requests.Add(1) == 3 {
+			close(released)
+		}
+		select {
+		case <-released:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "http://")
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "gcr.io/project/init:v1"}},
+				Containers:     []corev1.Container{{Name: "app", Image: "gcr.io/project/app:v1"}},
+				Volumes: []corev1.Volume{{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						Image: &corev1.ImageVolumeSource{Reference: "gcr.io/project/data:v1"},
+					},
+				}},
+			},
+		}
+	}
+
+	newDefaulter := func() PodDefaulter {
+		return BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+			Rewriter: k8s.NewImageRewriter(map[string]string{"gcr.io": mirror}, nil),
+			MirrorCheck: buildMirrorCheck(&apiv1.MirrorCheck{
+				OnMissing: apiv1.MirrorCheckReject,
+				RegistryQuery: apiv1.RegistryQuery{
+					Timeout:             apiv1.Duration(time.Minute),
+					PlainHTTPRegistries: []string{mirror},
+				},
+			}, Dependencies{}),
+		}, nsf)
+	}
+
+	t.Run("images checked concurrently", func(t *testing.T) {
+		pod := newPod()
+		modified, err := newDefaulter()(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		assert.Equal(t, []string{
+			mirror + "/project/init:v1",
+			mirror + "/project/app:v1",
+			mirror + "/project/data:v1",
+		}, []string{
+			pod.Spec.InitContainers[0].Image,
+			pod.Spec.Containers[0].Image,
+			pod.Spec.Volumes[0].Image.Reference,
+		})
+	})
+
+	t.Run("checks limited by the request deadline", func(t *testing.T) {
+		unresponsive.Store(true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		pod := newPod()
+		start := time.Now()
+		_, err := newDefaulter()(ctx, pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_reinvocation(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	// the rewritten images match the override again
+	defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+		Rewriter: k8s.NewImageRewriter(map[string]string{"gcr.io": "gcr.io/mirror"}, nil),
+	}, nsf)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "gcr.io/project/app:v1"}},
+		},
+	}
+	ns := &Namespace{Namespace: &corev1.Namespace{}}
+
+	modified, err := defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.True(t, modified)
+
+	// the container injected by a later webhook is rewritten, the other ones
+	// are kept
+	pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "sidecar", Image: "gcr.io/project/sidecar:v1"})
+
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, "gcr.io/mirror/project/app:v1", pod.Spec.Containers[0].Image)
+	assert.Equal(t, "gcr.io/mirror/project/sidecar:v1", pod.Spec.Containers[1].Image)
+
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	// the image replaced by a later webhook is rewritten again
+	pod.Spec.Containers[1].Image = "gcr.io/project/sidecar:v2"
+
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, "gcr.io/mirror/project/sidecar:v2", pod.Spec.Containers[1].Image)
+
+	// the original of the image rewritten again is the replacing image
+	originals, err := apiv1.OriginalImages(pod)
+	require.NoError(t, err)
+	assert.Equal(t, "gcr.io/project/sidecar:v2", originals["sidecar"])
+
+	// the pinned image is not rewritten again
+	pod.Spec.Containers[0].Image = "gcr.io/mirror/project/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	recordPinnedResults(pod, map[string]string{"app": "v1"})
+
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	originals, err = apiv1.OriginalImages(pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":     "gcr.io/project/app:v1",
+		"sidecar": "gcr.io/project/sidecar:v2",
+	}, originals)
+
+	results, err := apiv1.RewrittenImages(pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":     pod.Spec.Containers[0].Image,
+		"sidecar": "gcr.io/mirror/project/sidecar:v2",
+	}, results)
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_exclusions(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, []apiv1.NamespaceFeatureRule{
+		{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"vendor": "true"},
+			},
+			Features: []string{apiv1.ExcludeImagesFeature("team"), apiv1.ExcludeImagesFeature("vendor")},
+		},
+		{
+			Names:    []string{"team-*"},
+			Features: []string{apiv1.ExcludeImagesFeature("team")},
+		},
+	}, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	cfg := apiv1.Config{
+		ImageRewriteExclusions: &apiv1.ImageRewriteExclusions{
+			ImageExclusions: apiv1.ImageExclusions{Images: []string{"gcr.io/vendor/licensed"}},
+			Named: map[string]apiv1.ImageExclusions{
+				"team":   {Prefixes: []string{"gcr.io/team-a"}},
+				"vendor": {Images: []string{"gcr.io/project/app"}},
+			},
+		},
+	}
+	exclusions, err := cfg.ImageExclusionMatcher()
+	require.NoError(t, err)
+
+	defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+		Rewriter:   k8s.NewImageRewriter(map[string]string{"gcr.io": "mirror.local"}, nil),
+		Exclusions: exclusions,
+	}, nsf)
+
+	tcs := []struct {
+		namespace string
+		labels    map[string]string
+		expected  []string
+	}{
+		{
+			namespace: "default",
+			expected:  []string{"gcr.io/vendor/licensed:v1", "mirror.local/team-a/app:v1", "mirror.local/project/app:v1"},
+		},
+		{
+			namespace: "team-a",
+			expected:  []string{"gcr.io/vendor/licensed:v1", "gcr.io/team-a/app:v1", "mirror.local/project/app:v1"},
+		},
+		{
+			namespace: "team-b",
+			labels:    map[string]string{"vendor": "true"},
+			expected:  []string{"gcr.io/vendor/licensed:v1", "gcr.io/team-a/app:v1", "gcr.io/project/app:v1"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.namespace, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: tc.namespace},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "licensed", Image: "gcr.io/vendor/licensed:v1"},
+						{Name: "team", Image: "gcr.io/team-a/app:v1"},
+						{Name: "app", Image: "gcr.io/project/app:v1"},
+					},
+				},
+			}
+
+			ns := &Namespace{Namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   tc.namespace,
+				Labels: tc.labels,
+			}}}
+
+			_, err := defaulter(context.Background(), pod, ns)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, c := range pod.Spec.Containers {
+				actual = append(actual, c.Image)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_BuildPodDefaulterAlterImgRegistry_imageVolumes(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	defaulter := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+		Rewriter: k8s.NewImageRewriter(map[string]string{"ghcr.io": "ghcr.io/mirror"}, nil),
+	}, nsf)
+
+	newVolume := func(name, reference string) corev1.Volume {
+		return corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{Image: &corev1.ImageVolumeSource{Reference: reference}},
+		}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "mirror.local/app:v1"}},
+			Volumes: []corev1.Volume{
+				newVolume("model", "ghcr.io/org/model:v1"),
+				newVolume("data", "mirror.local/data:v1"),
+				{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+	ns := &Namespace{Namespace: &corev1.Namespace{}}
+
+	modified, err := defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, "ghcr.io/mirror/org/model:v1", pod.Spec.Volumes[0].Image.Reference)
+	assert.Equal(t, "mirror.local/data:v1", pod.Spec.Volumes[1].Image.Reference)
+
+	// the rewritten volumes are not rewritten again
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.False(t, modified)
+	assert.Equal(t, "ghcr.io/mirror/org/model:v1", pod.Spec.Volumes[0].Image.Reference)
+
+	originals, err := apiv1.OriginalVolumeImages(pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"model": "ghcr.io/org/model:v1"}, originals)
+	assert.NotContains(t, pod.Annotations, apiv1.AnnotationOriginalImages)
+
+	// the malformed references are rejected
+	pod.Spec.Volumes = append(pod.Spec.Volumes, newVolume("invalid", "ghcr.io/org/model:v1:v2"))
+	_, err = defaulter(context.Background(), pod, ns)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `spec.volumes[3].image.reference: Invalid value: "ghcr.io/org/model:v1:v2"`)
+}
+
+func Test_BuildPodDefaulterAlterAnnotationImgRegistry(t *testing.T) {
+	const proxyImage = "sidecar.istio.io/proxyImage"
+
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationAlterImgRegistry})
+	require.NoError(t, err)
+
+	opts := AlterImgRegistryOpts{
+		Rewriter: k8s.NewImageRewriter(map[string]string{"docker.io": "docker.io/mirror"}, nil),
+	}
+	ns := &Namespace{Namespace: &corev1.Namespace{}}
+
+	t.Run("rewrite", func(t *testing.T) {
+		defaulter := BuildPodDefaulterAlterAnnotationImgRegistry([]string{proxyImage, "other.io/image"}, opts, nsf)
+
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				proxyImage:      "istio/proxyv2:1.24.0",
+				"other.io/info": "docker.io/library/nginx",
+			},
+		}}
+
+		modified, err := defaulter(context.Background(), pod, ns)
+		require.NoError(t, err)
+		assert.True(t, modified)
+		assert.Equal(t, "docker.io/mirror/istio/proxyv2:1.24.0", pod.Annotations[proxyImage])
+		assert.Equal(t, "docker.io/library/nginx", pod.Annotations["other.io/info"])
+
+		// the rewritten annotation is not rewritten again
+		modified, err = defaulter(context.Background(), pod, ns)
+		require.NoError(t, err)
+		assert.False(t, modified)
+		assert.Equal(t, "docker.io/mirror/istio/proxyv2:1.24.0", pod.Annotations[proxyImage])
+
+		originals, err := apiv1.OriginalAnnotations(pod)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{proxyImage: "istio/proxyv2:1.24.0"}, originals)
+	})
+
+	t.Run("invalid image", func(t *testing.T) {
+		defaulter := BuildPodDefaulterAlterAnnotationImgRegistry([]string{proxyImage}, opts, nsf)
+
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{proxyImage: "istio/proxyv2:1.24:0"},
+		}}
+
+		_, err := defaulter(context.Background(), pod, ns)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), `metadata.annotations[sidecar.istio.io/proxyImage]: Invalid value: "istio/proxyv2:1.24:0"`)
+	})
+}
+
+func Test_BuildPodDefaulterPinImgDigest(t *testing.T) {
+	const (
+		digestV1     = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		digestLatest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+		digestPinned = "sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+	)
+
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationPinImgDigest})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/app/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", digestV1)
+		case "/v2/project/init/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", digestLatest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "http://")
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: mirror + "/project/init"}},
+				Containers: []corev1.Container{
+					{Name: "app", Image: mirror + "/project/app:v1"},
+					{Name: "pinned", Image: mirror + "/project/pinned@" + digestPinned},
+					{Name: "missing", Image: mirror + "/project/missing:v1"},
+				},
+			},
+		}
+	}
+
+	newDefaulter := func(onFailure string) PodDefaulter {
+		return BuildPodDefaulterPinImgDigest(buildPinImgDigestOpts(&apiv1.DigestPinning{
+			OnFailure: onFailure,
+			RegistryQuery: apiv1.RegistryQuery{
+				PlainHTTPRegistries: []string{mirror},
+			},
+		}, Dependencies{}), nsf)
+	}
+
+	t.Run("keep the tags of the unresolved images", func(t *testing.T) {
+		pod := newPod()
+		modified, err := newDefaulter(apiv1.DigestPinningKeepTag)(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		assert.Equal(t, mirror+"/project/init@"+digestLatest, pod.Spec.InitContainers[0].Image)
+		assert.Equal(t, []string{
+			mirror + "/project/app@" + digestV1,
+			mirror + "/project/pinned@" + digestPinned,
+			mirror + "/project/missing:v1",
+		}, []string{pod.Spec.Containers[0].Image, pod.Spec.Containers[1].Image, pod.Spec.Containers[2].Image})
+
+		tags, err := apiv1.OriginalTags(pod)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"init": "latest", "app": "v1"}, tags)
+	})
+
+	t.Run("reject the unresolved images", func(t *testing.T) {
+		pod := newPod()
+		_, err := newDefaulter(apiv1.DigestPinningReject)(context.Background(), pod, &Namespace{Namespace: &corev1.Namespace{}})
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), `spec.containers[2].image: Invalid value: "`+mirror+`/project/missing:v1"`)
+		assert.Contains(t, err.Error(), "manifest not found")
+	})
+}
+
+func Test_BuildPodDefaulterPinImgDigest_concurrency(t *testing.T) {
+	const digest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationPinImgDigest})
+	require.NoError(t, err)
+
+	// the registry responds once both images are queried, i.e. only if they
+	// are resolved concurrently
+	var requests atomic.Int32
+	released := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			close(released)
+		}
+		select {
+		case <-released:
+			w.Header().Set("Docker-Content-Digest", digest)
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	mirror := strings.TrimPrefix(srv.URL, "http://")
+
+	defaulter := BuildPodDefaulterPinImgDigest(buildPinImgDigestOpts(&apiv1.DigestPinning{
+		OnFailure: apiv1.DigestPinningReject,
+		RegistryQuery: apiv1.RegistryQuery{
+			Timeout:             apiv1.Duration(time.Minute),
+			PlainHTTPRegistries: []string{mirror},
+		},
+	}, Dependencies{}), nsf)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: mirror + "/project/init:v1"}},
+			Containers:     []corev1.Container{{Name: "app", Image: mirror + "/project/app:v1"}},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modified, err := defaulter(ctx, pod, &Namespace{Namespace: &corev1.Namespace{}})
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, mirror+"/project/init@"+digest, pod.Spec.InitContainers[0].Image)
+	assert.Equal(t, mirror+"/project/app@"+digest, pod.Spec.Containers[0].Image)
+}
+
+func Test_BuildPodDefaulterSetImgPullPolicy(t *testing.T) {
+	nsf, err := apiv1.NewNamespaceFeatureMatcher(nil, nil, []string{apiv1.AnnotationSetImgPullPolicy})
+	require.NoError(t, err)
+
+	var cfg apiv1.Config
+	defaulter := BuildPodDefaulterSetImgPullPolicy(cfg.ImagePullPolicyRulesOrDefault(), nsf)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "init", Image: "mirror.local/init", ImagePullPolicy: corev1.PullIfNotPresent},
+			},
+			Containers: []corev1.Container{
+				{Name: "latest", Image: "mirror.local/app:latest", ImagePullPolicy: corev1.PullAlways},
+				{Name: "tagged", Image: "mirror.local/app:v1", ImagePullPolicy: corev1.PullIfNotPresent},
+				{
+					Name:            "pinned",
+					Image:           "mirror.local/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+					ImagePullPolicy: corev1.PullAlways,
+				},
+			},
+		},
+	}
+	ns := &Namespace{Namespace: &corev1.Namespace{}}
+
+	modified, err := defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, corev1.PullAlways, pod.Spec.InitContainers[0].ImagePullPolicy)
+	assert.Equal(t, corev1.PullAlways, pod.Spec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, corev1.PullIfNotPresent, pod.Spec.Containers[1].ImagePullPolicy)
+	assert.Equal(t, corev1.PullIfNotPresent, pod.Spec.Containers[2].ImagePullPolicy)
+
+	originals, err := apiv1.OriginalPullPolicies(pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"init":   string(corev1.PullIfNotPresent),
+		"pinned": string(corev1.PullAlways),
+	}, originals)
+
+	// the policies set already are kept
+	modified, err = defaulter(context.Background(), pod, ns)
+	require.NoError(t, err)
+	assert.False(t, modified)
+}
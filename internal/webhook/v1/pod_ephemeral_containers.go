@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const ephemeralContainersWebhookPath = "/mutate--v1-pod-ephemeralcontainers"
+
+// +kubebuilder:webhook:path=/mutate--v1-pod-ephemeralcontainers,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods/ephemeralcontainers,verbs=update,versions=v1,name=mpod-ephemeralcontainers-v1.kb.io,admissionReviewVersions=v1,timeoutSeconds=10
+
+// EphemeralContainers - returns the defaulter of the ephemeral containers
+// added with the pods/ephemeralcontainers subresource, e.g. by 'kubectl debug'
+func (w *PodWebhook) EphemeralContainers() webhook.CustomDefaulter {
+	return &ephemeralContainersDefaulter{webhook: w}
+}
+
+type ephemeralContainersDefaulter struct {
+	webhook *PodWebhook
+}
+
+// Default - delegates to the defaulters active when the admission started
+func (d *ephemeralContainersDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	return d.webhook.current.Load().DefaultEphemeralContainers(ctx, obj)
+}
+
+// DefaultEphemeralContainers - applies the ephemeral defaulters to the
+// ephemeral containers added by the update.
+//
+// Only the ephemeral containers of a running pod can be changed, so the
+// defaulters run against a copy of the pod holding the added ephemeral
+// containers as its containers, and only the containers are copied back. The
+// volumes can not be added, so the volume mounts of volumes missing in the pod
+// are dropped. The pull secrets of the pod are kept as they are.
+func (d *podCustomDefaulter) DefaultEphemeralContainers(ctx context.Context, obj runtime.Object) (err error) {
+	defer recoverDefaulterPanic(&err)
+
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("expected an Pod object but got %T", obj)
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var oldPod corev1.Pod
+	if err := json.Unmarshal(req.OldObject.Raw, &oldPod); err != nil {
+		return fmt.Errorf("unable to decode the old pod: %w", err)
+	}
+
+	added := addedEphemeralContainers(&oldPod, pod)
+	if len(added) == 0 || len(d.ephemeralDefaulters) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultingTimeout)
+	defer cancel()
+
+	ns, err := d.namespace(ctx, pod.Namespace)
+	if err != nil {
+		return err
+	}
+
+	view := ephemeralContainersView(pod, added)
+	if _, err := runDefaulters(ctx, d.ephemeralDefaulters, view, ns); err != nil {
+		return ephemeralContainersError(err, added)
+	}
+
+	for i, index := range added {
+		container := view.Spec.Containers[i]
+		container.VolumeMounts = slices.DeleteFunc(container.VolumeMounts, func(vm corev1.VolumeMount) bool {
+			if slices.ContainsFunc(pod.Spec.Volumes, func(v corev1.Volume) bool {
+				return v.Name == vm.Name
+			}) {
+				return false
+			}
+
+			slog.Debug("volume not found in the pod, dropping volume mount",
+				"container-name", container.Name,
+				"volume-name", vm.Name)
+			return true
+		})
+
+		pod.Spec.EphemeralContainers[index].EphemeralContainerCommon = corev1.EphemeralContainerCommon(container)
+	}
+	return nil
+}
+
+// addedEphemeralContainers - returns the indices of the ephemeral containers
+// missing in the old pod
+func addedEphemeralContainers(oldPod, pod *corev1.Pod) []int {
+	var result []int
+	for i, ec := range pod.Spec.EphemeralContainers {
+		if slices.ContainsFunc(oldPod.Spec.EphemeralContainers, func(old corev1.EphemeralContainer) bool {
+			return old.Name == ec.Name
+		}) {
+			continue
+		}
+		result = append(result, i)
+	}
+	return result
+}
+
+// ephemeralContainersView - returns a copy of the pod with the ephemeral
+// containers of the given indices as its containers
+func ephemeralContainersView(pod *corev1.Pod, indices []int) *corev1.Pod {
+	result := pod.DeepCopy()
+	result.Spec.InitContainers = nil
+	result.Spec.Containers = make([]corev1.Container, 0, len(indices))
+	result.Spec.EphemeralContainers = nil
+
+	for _, index := range indices {
+		result.Spec.Containers = append(result.Spec.Containers,
+			corev1.Container(pod.Spec.EphemeralContainers[index].EphemeralContainerCommon))
+	}
+	return result
+}
+
+// ephemeralContainersError - points the fields of the invalid error returned
+// for the view of the pod to the ephemeral containers
+func ephemeralContainersError(err error, indices []int) error {
+	var statusErr *apierrors.StatusError
+	if !errors.As(err, &statusErr) || !apierrors.IsInvalid(err) {
+		return err
+	}
+
+	status := statusErr.Status()
+	status.Details = status.Details.DeepCopy()
+	for i, index := range indices {
+		from := field.NewPath("spec", "containers").Index(i).String() + "."
+		to := field.NewPath("spec", "ephemeralContainers").Index(index).String() + "."
+
+		status.Message = strings.ReplaceAll(status.Message, from, to)
+		if status.Details == nil {
+			continue
+		}
+		for j, cause := range status.Details.Causes {
+			if strings.HasPrefix(cause.Field, from) {
+				status.Details.Causes[j].Field = to + strings.TrimPrefix(cause.Field, from)
+			}
+		}
+	}
+	return &apierrors.StatusError{ErrStatus: status}
+}
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func Test_PodWebhook_EphemeralContainers(t *testing.T) {
+	ctb := k8s.ClusterTrustBundle{
+		Name:            "ctb",
+		CertWritePath:   "ca.pem",
+		VolumeMountPath: "/etc/ssl/certs",
+		VolumeName:      "certs",
+	}
+
+	podWebhook, err := NewPodWebhook(&apiv1.Config{
+		Overrides:                 map[string]string{"gcr.io": "mirror.local"},
+		ImagePullSecretName:       "registry-credentials",
+		ImagePullSecretNamespace:  "kyma-system",
+		ClusterTrustBundleMapping: &ctb,
+		DefaultFeatures: []string{
+			apiv1.AnnotationAlterImgRegistry,
+			apiv1.AnnotationSetPullSecret,
+			apiv1.AnnotationSetFipsMode,
+			apiv1.AnnotationAddClusterTrustBundle,
+		},
+	}, Dependencies{
+		GetNamespace: func(context.Context, string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{}, nil
+		},
+	})
+	require.NoError(t, err)
+
+	newEphemeralContainer := func(name, image string) corev1.EphemeralContainer {
+		return corev1.EphemeralContainer{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: image},
+		}
+	}
+
+	debugger := newEphemeralContainer("debugger", "gcr.io/project/debug:v1")
+
+	oldPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: corev1.PodSpec{
+			Containers:          []corev1.Container{{Name: "app", Image: "mirror.local/project/app:v1"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugger},
+		},
+	}
+
+	admissionContext := func(t *testing.T) context.Context {
+		raw, err := json.Marshal(oldPod)
+		require.NoError(t, err)
+
+		return admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation:   admissionv1.Update,
+				SubResource: "ephemeralcontainers",
+				OldObject:   runtime.RawExtension{Raw: raw},
+			},
+		})
+	}
+
+	t.Run("added ephemeral containers are defaulted", func(t *testing.T) {
+		pod := oldPod.DeepCopy()
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers,
+			newEphemeralContainer("debugger-2", "gcr.io/project/debug:v2"))
+
+		require.NoError(t, podWebhook.EphemeralContainers().Default(admissionContext(t), pod))
+
+		// the existing ephemeral container can not be changed
+		assert.Equal(t, debugger, pod.Spec.EphemeralContainers[0])
+
+		added := pod.Spec.EphemeralContainers[1]
+		assert.Equal(t, "mirror.local/project/debug:v2", added.Image)
+		assert.Equal(t, []corev1.EnvVar{envVarKymaFipsModeEnabled}, added.Env)
+		// the trust bundle volume is missing in the pod and can not be added
+		assert.Empty(t, added.VolumeMounts)
+
+		// the pod itself is not changed
+		assert.Equal(t, oldPod.Spec.Containers, pod.Spec.Containers)
+		assert.Empty(t, pod.Spec.ImagePullSecrets)
+		assert.Empty(t, pod.Spec.Volumes)
+		assert.Empty(t, pod.Annotations)
+	})
+
+	t.Run("trust bundle mounted if the pod has the volume", func(t *testing.T) {
+		pod := oldPod.DeepCopy()
+		pod.Spec.Volumes = []corev1.Volume{ctb.ClusterTrustedBundle()}
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers,
+			newEphemeralContainer("debugger-2", "busybox"))
+
+		require.NoError(t, podWebhook.EphemeralContainers().Default(admissionContext(t), pod))
+
+		added := pod.Spec.EphemeralContainers[1]
+		assert.Equal(t, "busybox", added.Image)
+		assert.Equal(t, []corev1.VolumeMount{ctb.VolumeMount()}, added.VolumeMounts)
+	})
+
+	t.Run("invalid image of an added ephemeral container", func(t *testing.T) {
+		pod := oldPod.DeepCopy()
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers,
+			newEphemeralContainer("debugger-2", "gcr.io/Project/debug:v2"))
+
+		err := podWebhook.EphemeralContainers().Default(admissionContext(t), pod)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), `spec.ephemeralContainers[1].image: Invalid value: "gcr.io/Project/debug:v2"`)
+
+		var statusErr *apierrors.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, "spec.ephemeralContainers[1].image", statusErr.ErrStatus.Details.Causes[0].Field)
+	})
+
+	t.Run("no ephemeral container added", func(t *testing.T) {
+		pod := oldPod.DeepCopy()
+
+		require.NoError(t, podWebhook.EphemeralContainers().Default(admissionContext(t), pod))
+		assert.Equal(t, oldPod, *pod)
+	})
+}
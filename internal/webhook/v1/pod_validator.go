@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods;pods/ephemeralcontainers,verbs=create;update,versions=v1,name=vpod-v1.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &PodWebhook{}
+
+// ValidateCreate - checks the images of the pod against the registry policy
+// of its namespace
+func (w *PodWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.current.Load().validateRegistries(ctx, nil, obj)
+}
+
+// ValidateUpdate - checks the images changed or added by the update, e.g. the
+// images of the added ephemeral containers
+func (w *PodWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, w.current.Load().validateRegistries(ctx, oldObj, newObj)
+}
+
+func (w *PodWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+const (
+	imageSourceContainer = "container"
+	imageSourceVolume    = "volume"
+)
+
+// containerImage - the image of a container or the reference of an image
+// volume with its field path
+type containerImage struct {
+	fldPath *field.Path
+	// source - either a container or a volume
+	source string
+	name   string
+	image  string
+}
+
+// key - identifies the image across the pod versions, the volume names can be
+// equal to the container names
+func (c containerImage) key() string {
+	return c.source + "/" + c.name
+}
+
+// containerImages - returns the images of all kinds of containers of the pod
+// and the references of its image volumes
+func containerImages(pod *corev1.Pod) []containerImage {
+	specPath := field.NewPath("spec")
+
+	var result []containerImage
+	for i, c := range pod.Spec.InitContainers {
+		result = append(result, containerImage{
+			fldPath: specPath.Child("initContainers").Index(i).Child("image"),
+			source:  imageSourceContainer,
+			name:    c.Name,
+			image:   c.Image,
+		})
+	}
+	for i, c := range pod.Spec.Containers {
+		result = append(result, containerImage{
+			fldPath: specPath.Child("containers").Index(i).Child("image"),
+			source:  imageSourceContainer,
+			name:    c.Name,
+			image:   c.Image,
+		})
+	}
+	for i, c := range pod.Spec.EphemeralContainers {
+		result = append(result, containerImage{
+			fldPath: specPath.Child("ephemeralContainers").Index(i).Child("image"),
+			source:  imageSourceContainer,
+			name:    c.Name,
+			image:   c.Image,
+		})
+	}
+	for i, v := range pod.Spec.Volumes {
+		if v.Image == nil || v.Image.Reference == "" {
+			continue
+		}
+		result = append(result, containerImage{
+			fldPath: specPath.Child("volumes").Index(i).Child("image", "reference"),
+			source:  imageSourceVolume,
+			name:    v.Name,
+			image:   v.Image.Reference,
+		})
+	}
+	return result
+}
+
+// validateRegistries - rejects the pod if any of the images added or changed
+// since the old pod is not allowed by the registry policy of the namespace;
+// all the images are checked if the old pod is not set
+func (d *podCustomDefaulter) validateRegistries(ctx context.Context, oldObj, newObj runtime.Object) error {
+	if d.registryPolicies.Empty() {
+		return nil
+	}
+
+	pod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("expected an Pod object but got %T", newObj)
+	}
+
+	// the container names are unique across all the kinds of containers, the
+	// volume names across the volumes
+	unchanged := map[string]string{}
+	if oldPod, ok := oldObj.(*corev1.Pod); ok {
+		for _, c := range containerImages(oldPod) {
+			unchanged[c.key()] = c.image
+		}
+	}
+
+	namespace, err := d.GetNamespace(ctx, pod.Namespace)
+	if err != nil {
+		slog.Error("unable to get namespace", "error", err)
+		return err
+	}
+
+	policy := d.registryPolicies.Policy(namespace)
+	if policy == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for _, c := range containerImages(pod) {
+		if image, found := unchanged[c.key()]; found && image == c.image {
+			continue
+		}
+
+		name, err := k8s.ParseImage(c.image)
+		if err != nil {
+			errs = append(errs, field.Invalid(c.fldPath, c.image, err.Error()))
+			continue
+		}
+
+		if reason := policy.Check(name); reason != "" {
+			slog.Debug("image rejected by registry policy",
+				c.source+"-name", c.name,
+				"image-name", c.image,
+				"reason", reason)
+
+			errs = append(errs, field.Forbidden(c.fldPath,
+				fmt.Sprintf("image '%s' of %s '%s' in namespace '%s': %s",
+					c.image, c.source, c.name, pod.Namespace, reason)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(), pod.Name, errs)
+}
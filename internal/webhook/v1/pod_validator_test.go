@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_PodWebhook_validateRegistries(t *testing.T) {
+	namespaces := map[string]*corev1.Namespace{
+		"ns2-restricted": {ObjectMeta: metav1.ObjectMeta{
+			Name:   "ns2-restricted",
+			Labels: map[string]string{"restricted": "true"},
+		}},
+		"team-a": {ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		"other":  {ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	}
+
+	podWebhook, err := NewPodWebhook(&apiv1.Config{
+		Overrides: map[string]string{},
+		RegistryPolicies: []apiv1.RegistryPolicy{
+			{
+				Name: "mirror-only",
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"restricted": "true"},
+				},
+				Allowed: []string{"mirror.local", "gcr.io/project-a"},
+			},
+			{
+				Names:  []string{"team-*"},
+				Denied: []string{"docker.io"},
+			},
+		},
+	}, Dependencies{
+		GetNamespace: func(_ context.Context, name string) (*corev1.Namespace, error) {
+			return namespaces[name], nil
+		},
+	})
+	require.NoError(t, err)
+
+	newPod := func(namespace string, images ...string) *corev1.Pod {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: namespace}}
+		for i, image := range images {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+				Name:  []string{"app", "sidecar", "proxy"}[i],
+				Image: image,
+			})
+		}
+		return &pod
+	}
+
+	tcs := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected []string
+	}{
+		{
+			name: "allowed registries and repository prefixes",
+			pod:  newPod("ns2-restricted", "mirror.local/library/nginx:latest", "gcr.io/project-a/app:v1"),
+		},
+		{
+			name: "registries not allowed",
+			pod:  newPod("ns2-restricted", "mirror.local/app:v1", "gcr.io/project-b/app:v1", "nginx"),
+			expected: []string{
+				`spec.containers[1].image: Forbidden: image 'gcr.io/project-b/app:v1' of container 'sidecar' in namespace 'ns2-restricted': registry 'gcr.io' is not allowed by the registry policy 'mirror-only', allowed: mirror.local, gcr.io/project-a`,
+				`spec.containers[2].image: Forbidden: image 'nginx' of container 'proxy' in namespace 'ns2-restricted': registry 'docker.io' is not allowed by the registry policy 'mirror-only', allowed: mirror.local, gcr.io/project-a`,
+			},
+		},
+		{
+			name: "denied registry",
+			pod:  newPod("team-a", "quay.io/org/app:v1", "index.docker.io/bitnami/redis"),
+			expected: []string{
+				`spec.containers[1].image: Forbidden: image 'index.docker.io/bitnami/redis' of container 'sidecar' in namespace 'team-a': 'docker.io' is denied by the registry policy 'registryPolicies[1]'`,
+			},
+		},
+		{
+			name: "invalid image reference",
+			pod:  newPod("team-a", "quay.io/Org/app:v1"),
+			expected: []string{
+				`spec.containers[0].image: Invalid value: "quay.io/Org/app:v1"`,
+			},
+		},
+		{
+			name: "image volumes",
+			pod: func() *corev1.Pod {
+				pod := newPod("ns2-restricted", "mirror.local/app:v1")
+				pod.Spec.Volumes = []corev1.Volume{
+					{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					{Name: "model", VolumeSource: corev1.VolumeSource{
+						Image: &corev1.ImageVolumeSource{Reference: "mirror.local/org/model:v1"},
+					}},
+					{Name: "data", VolumeSource: corev1.VolumeSource{
+						Image: &corev1.ImageVolumeSource{Reference: "ghcr.io/org/data:v1"},
+					}},
+				}
+				return pod
+			}(),
+			expected: []string{
+				`spec.volumes[2].image.reference: Forbidden: image 'ghcr.io/org/data:v1' of volume 'data' in namespace 'ns2-restricted': registry 'ghcr.io' is not allowed by the registry policy 'mirror-only', allowed: mirror.local, gcr.io/project-a`,
+			},
+		},
+		{
+			name: "namespace without registry policy",
+			pod:  newPod("other", "nginx"),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := podWebhook.ValidateCreate(context.Background(), tc.pod)
+			if len(tc.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.True(t, apierrors.IsInvalid(err))
+			for _, expected := range tc.expected {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+
+	t.Run("only changed images are validated on update", func(t *testing.T) {
+		oldPod := newPod("ns2-restricted", "nginx")
+		pod := oldPod.DeepCopy()
+		pod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+		}}
+
+		_, err := podWebhook.ValidateUpdate(context.Background(), oldPod, pod)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.ephemeralContainers[0].image: Forbidden: image 'busybox' of container 'debugger'`)
+		assert.NotContains(t, err.Error(), "spec.containers[0]")
+
+		pod.Spec.EphemeralContainers[0].Image = "mirror.local/library/busybox:latest"
+		_, err = podWebhook.ValidateUpdate(context.Background(), oldPod, pod)
+		require.NoError(t, err)
+	})
+}
+
+func Test_PodWebhook_noConfiguration(t *testing.T) {
+	podWebhook, err := NewPodWebhook(nil, Dependencies{
+		GetNamespace: func(_ context.Context, name string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		},
+	})
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "nginx"}},
+		},
+	}
+	expected := pod.DeepCopy()
+
+	// the pods are admitted as they are until a configuration is applied
+	require.NoError(t, podWebhook.Default(context.Background(), pod))
+	assert.Equal(t, expected, pod)
+
+	_, err = podWebhook.ValidateCreate(context.Background(), pod)
+	require.NoError(t, err)
+
+	commit, err := podWebhook.Update(&apiv1.Config{
+		RegistryPolicies: []apiv1.RegistryPolicy{{
+			Names:  []string{"test"},
+			Denied: []string{"docker.io"},
+		}},
+	})
+	require.NoError(t, err)
+	commit()
+
+	_, err = podWebhook.ValidateCreate(context.Background(), pod)
+	require.Error(t, err)
+}
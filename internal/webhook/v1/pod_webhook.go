@@ -20,31 +20,32 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
 
+	rtbootstrapperv1alpha1 "github.com/kyma-project/rt-bootstrapper/api/v1alpha1"
+	"github.com/kyma-project/rt-bootstrapper/internal/credentials"
+	"github.com/kyma-project/rt-bootstrapper/internal/registry"
 	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // SetupPodWebhookWithManager registers the webhook for Pod in the manager.
-func SetupPodWebhookWithManager(mgr ctrl.Manager, cfg *apiv1.Config) error {
+func SetupPodWebhookWithManager(
+	mgr ctrl.Manager,
+	cfg *apiv1.Config,
+	creds *credentials.Store,
+	mirrorHealth *registry.HealthProber) (*PodWebhook, error) {
 
-	slog.Info("setting up webhook", "cfg", cfg)
-
-	nsf := apiv1.NamespaceFeatures{}
-	if cfg.NamespaceFeatures != nil {
-		nsf = *cfg.NamespaceFeatures
-		slog.Info("default features configuration found", "default-features", nsf.Features)
-	}
-
-	d1 := BuildPodDefaulterAddImagePullSecrets(cfg.ImagePullSecretName, nsf)
-	d2 := BuildPodDefaulterAlterImgRegistry(cfg.Overrides, nsf)
-	d3 := BuildDefaulterFipsMode(nsf)
-
-	getNamespace := func(ctx context.Context, name string) (map[string]string, error) {
+	getNamespace := func(ctx context.Context, name string) (*corev1.Namespace, error) {
 		var ns corev1.Namespace
 		if err := mgr.GetClient().Get(ctx, client.ObjectKey{
 			Name: name,
@@ -52,40 +53,260 @@ func SetupPodWebhookWithManager(mgr ctrl.Manager, cfg *apiv1.Config) error {
 			return nil, err
 		}
 
-		result := ns.Annotations
-
 		slog.Default().WithGroup("get-namespace").Debug("namespace fetched",
 			"name", name,
-			"annotations", result)
+			"annotations", ns.Annotations,
+			"labels", ns.Labels)
+
+		return &ns, nil
+	}
 
-		return result, nil
+	getPolicy := func(ctx context.Context, namespace string) (*rtbootstrapperv1alpha1.NamespaceBootstrapPolicy, error) {
+		var policy rtbootstrapperv1alpha1.NamespaceBootstrapPolicy
+		err := mgr.GetClient().Get(ctx, client.ObjectKey{
+			Name:      rtbootstrapperv1alpha1.NamespaceBootstrapPolicyName,
+			Namespace: namespace,
+		}, &policy)
+
+		// the policies are optional, also the CRD does not have to be installed
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Default().WithGroup("get-policy").Debug("namespace bootstrap policy fetched",
+			"namespace", namespace,
+			"spec", policy.Spec)
+
+		return &policy, nil
 	}
 
+	podWebhook, err := NewPodWebhook(cfg, Dependencies{
+		GetNamespace: getNamespace,
+		GetPolicy:    getPolicy,
+		Credentials:  creds,
+		MirrorHealth: mirrorHealth,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mgr.GetWebhookServer().Register(ephemeralContainersWebhookPath,
+		admission.WithCustomDefaulter(mgr.GetScheme(), &corev1.Pod{}, podWebhook.EphemeralContainers()))
+
+	return podWebhook, ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
+		WithDefaulter(podWebhook).
+		WithValidator(podWebhook).
+		Complete()
+}
+
+// Dependencies - the runtime dependencies of the defaulters; unlike the
+// defaulters, they are kept when the configuration is reloaded
+type Dependencies struct {
+	GetNamespace GetNamespace
+	GetPolicy    GetPolicy
+	// Credentials - the registries of the master pull secrets and their
+	// credentials, optional
+	Credentials *credentials.Store
+	// HTTPClient - the client the mirrors are checked with, optional
+	HTTPClient *http.Client
+	// MirrorHealth - the health of the mirrors the fallback mirrors are
+	// chosen by, optional; the mirrors are healthy without it
+	MirrorHealth *registry.HealthProber
+}
+
+// PodWebhook - defaults pods with the defaulters built from the active
+// configuration; the defaulters can be replaced at runtime while the
+// admissions in progress finish with the defaulters they started with
+type PodWebhook struct {
+	deps    Dependencies
+	current atomic.Pointer[podCustomDefaulter]
+}
+
+var _ webhook.CustomDefaulter = &PodWebhook{}
+
+// NewPodWebhook - returns the webhook with the defaulters built from the given
+// configuration; the defaulters are disabled until the first configuration is
+// applied if cfg is nil
+func NewPodWebhook(cfg *apiv1.Config, deps Dependencies) (*PodWebhook, error) {
+	result := PodWebhook{
+		deps: deps,
+	}
+
+	defaulter, err := buildPodCustomDefaulter(cfg, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	result.current.Store(defaulter)
+	return &result, nil
+}
+
+// Update - builds the defaulters from the given configuration and returns
+// the commit atomically replacing the active ones with them; the active
+// defaulters stay in place on failure
+func (w *PodWebhook) Update(cfg *apiv1.Config) (func(), error) {
+	defaulter, err := buildPodCustomDefaulter(cfg, w.deps)
+	if err != nil {
+		slog.Error("unable to build defaulters, keeping the active ones", "error", err)
+		return nil, fmt.Errorf("unable to build pod defaulters: %w", err)
+	}
+
+	return func() {
+		w.current.Store(defaulter)
+	}, nil
+}
+
+// Default - delegates to the defaulters active when the admission started
+func (w *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	return w.current.Load().Default(ctx, obj)
+}
+
+func buildPodCustomDefaulter(cfg *apiv1.Config, deps Dependencies) (*podCustomDefaulter, error) {
+	// no configuration is active yet, the pods are admitted as they are
+	if cfg == nil {
+		slog.Warn("no configuration, defaulters disabled")
+		return &podCustomDefaulter{}, nil
+	}
+
+	slog.Info("building defaulters", "cfg", cfg)
+
+	nsf, err := cfg.NamespaceFeatureMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	rewriter, err := cfg.ImageRewriter()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.MirrorFailover != nil {
+		rewriter = rewriter.WithFailover(cfg.MirrorFailover.Fallbacks, deps.MirrorHealth.Healthy)
+	}
+
+	exclusions, err := cfg.ImageExclusionMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	registryPolicies, err := cfg.RegistryPolicyMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	alterOpts := AlterImgRegistryOpts{
+		Rewriter:    rewriter,
+		SkipInvalid: cfg.SkipInvalidImages(),
+		MirrorCheck: buildMirrorCheck(cfg.MirrorCheck, deps),
+		Exclusions:  exclusions,
+	}
+
+	// the pull secrets are chosen by the registries of the rewritten images
+	d1 := BuildPodDefaulterAlterImgRegistry(alterOpts, nsf)
+	d2 := BuildPodDefaulterAddImagePullSecrets(cfg.PullSecrets(), deps.Credentials, nsf)
+	d3 := BuildDefaulterFipsMode(nsf)
+	// the trust bundle can be selected by the namespace bootstrap policy even
+	// if there is no default one
+	d4 := BuildDefaulterAddClusterTrustBundle(cfg.ClusterTrustBundleMapping, nsf)
+	d5 := BuildDefaulterTenantEnv()
+	// the second pass of the registry rewrite, the digests are resolved from
+	// the mirrors
+	d6 := BuildPodDefaulterPinImgDigest(buildPinImgDigestOpts(cfg.DigestPinningOrDefault(), deps), nsf)
+	// the annotations of a running pod are not changed with the ephemeral
+	// containers
+	d7 := BuildPodDefaulterAlterAnnotationImgRegistry(cfg.ImageAnnotations, alterOpts, nsf)
+	// the pull policies depend on the final images
+	d8 := BuildPodDefaulterSetImgPullPolicy(cfg.ImagePullPolicyRulesOrDefault(), nsf)
+
 	defaulter := podCustomDefaulter{
-		defaulters: []func(*corev1.Pod, map[string]string) (bool, error){
+		defaulters: []PodDefaulter{
 			d1,
+			d6,
+			d7,
+			d8,
 			d2,
 			d3,
+			d4,
+			d5,
+		},
+		// the pull secrets of a running pod can not be changed
+		ephemeralDefaulters: []PodDefaulter{
+			d1,
+			d6,
+			d8,
+			d3,
+			d4,
+			d5,
 		},
-		GetNsAnnotations: getNamespace,
+		GetNamespace:     deps.GetNamespace,
+		getPolicy:        deps.GetPolicy,
+		tenantPolicy:     cfg.TenantPolicy,
+		registryPolicies: registryPolicies,
 	}
 
-	// conditional defaulters
+	return &defaulter, nil
+}
 
-	if cfg.ClusterTrustBundleMapping != nil {
-		d4 := BuildDefaulterAddClusterTrustBundle(*cfg.ClusterTrustBundleMapping, nsf)
-		defaulter.defaulters = append(defaulter.defaulters, d4)
+// newManifestChecker - returns the client of the registries; the results
+// cached by the previous configuration are dropped
+func newManifestChecker(query apiv1.RegistryQuery, deps Dependencies) *registry.ManifestChecker {
+	var creds registry.Credentials
+	if deps.Credentials != nil {
+		creds = deps.Credentials
 	}
 
-	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
-		WithDefaulter(&defaulter).
-		Complete()
+	return registry.NewManifestChecker(registry.ManifestCheckerOpts{
+		Client:      deps.HTTPClient,
+		Credentials: creds,
+		TTL:         query.CacheTTLOrDefault(),
+		PlainHTTP:   query.PlainHTTPRegistries,
+	})
+}
+
+// buildMirrorCheck - returns the check of the rewritten images, nil if it is
+// disabled
+func buildMirrorCheck(cfg *apiv1.MirrorCheck, deps Dependencies) *MirrorCheck {
+	if cfg == nil {
+		return nil
+	}
+
+	return &MirrorCheck{
+		Checker: newManifestChecker(cfg.RegistryQuery, deps),
+		Reject:  cfg.RejectMissing(),
+		Timeout: cfg.TimeoutOrDefault(),
+	}
 }
 
-// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1
+// buildPinImgDigestOpts - returns the resolution of the image digests
+func buildPinImgDigestOpts(cfg *apiv1.DigestPinning, deps Dependencies) PinImgDigestOpts {
+	return PinImgDigestOpts{
+		Resolver: newManifestChecker(cfg.RegistryQuery, deps),
+		Reject:   cfg.RejectUnresolved(),
+		Timeout:  cfg.TimeoutOrDefault(),
+	}
+}
+
+const (
+	// podWebhookTimeout - the timeout of the mutating pod webhooks, it has to
+	// match the timeoutSeconds of their markers
+	podWebhookTimeout = 10 * time.Second
+	// defaultingTimeout - the time the defaulting of a pod can take; the rest
+	// of the webhook timeout is left for the response, so the registry queries
+	// are canceled before the API server gives up on the webhook
+	defaultingTimeout = podWebhookTimeout - time.Second
+)
+
+// the defaulters are idempotent, so the webhook is invoked again for the
+// containers added by the webhooks running later, e.g. the sidecar injectors
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1,reinvocationPolicy=IfNeeded,timeoutSeconds=10
 
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rt-bootstrapper.kyma-project.io,resources=namespacebootstrappolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=mutatingwebhookconfigurations,verbs=get;patch
+// +kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=validatingwebhookconfigurations,verbs=get;patch
 
 // podCustomDefaulter struct is responsible for setting default values on the custom resource of the
 // Kind Pod when those are created or updated.
@@ -93,55 +314,110 @@ func SetupPodWebhookWithManager(mgr ctrl.Manager, cfg *apiv1.Config) error {
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as it is used only for temporary operations and does not need to be deeply copied.
 type podCustomDefaulter struct {
-	defaulters []func(*corev1.Pod, map[string]string) (bool, error)
-	GetNsAnnotations
+	defaulters []PodDefaulter
+	// ephemeralDefaulters - the defaulters applied to the ephemeral
+	// containers added to a running pod
+	ephemeralDefaulters []PodDefaulter
+	GetNamespace
+	getPolicy GetPolicy
+	// tenantPolicy - limits of the namespace bootstrap policies, the policies
+	// are not read if not set
+	tenantPolicy *apiv1.TenantPolicy
+	// registryPolicies - the registries the pods can pull images from, per
+	// namespace
+	registryPolicies *apiv1.RegistryPolicyMatcher
 }
 
 var _ webhook.CustomDefaulter = &podCustomDefaulter{}
 
-type GetNsAnnotations = func(context.Context, string) (map[string]string, error)
+type GetNamespace = func(context.Context, string) (*corev1.Namespace, error)
 
 // Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Pod.
 func (d *podCustomDefaulter) Default(ctx context.Context, obj runtime.Object) (err error) {
-	defer func() {
-		r := recover()
-		if r == nil {
-			// no panic
-			return
-		}
-
-		switch x := r.(type) {
-		case string:
-			err = fmt.Errorf("%s", x)
-		case error:
-			err = x
-		default:
-			err = fmt.Errorf("unknown defaulting function panic: %s", r)
-		}
-	}()
+	defer recoverDefaulterPanic(&err)
 
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
 		return fmt.Errorf("expected an Pod object but got %T", obj)
 	}
 
-	nsAnnotations, err := d.GetNsAnnotations(ctx, pod.Namespace)
+	if len(d.defaulters) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultingTimeout)
+	defer cancel()
+
+	ns, err := d.namespace(ctx, pod.Namespace)
 	if err != nil {
-		slog.Error("unable to get namespace", "error", err)
 		return err
 	}
 
+	podDefaulted, err := runDefaulters(ctx, d.defaulters, pod, ns)
+	if err != nil || !podDefaulted {
+		return err
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[apiv1.AnnotationDefaulted] = "true"
+
+	return nil
+}
+
+// recoverDefaulterPanic - reports the panic of a defaulter as an error
+func recoverDefaulterPanic(err *error) {
+	r := recover()
+	if r == nil {
+		// no panic
+		return
+	}
+
+	switch x := r.(type) {
+	case string:
+		*err = fmt.Errorf("%s", x)
+	case error:
+		*err = x
+	default:
+		*err = fmt.Errorf("unknown defaulting function panic: %s", r)
+	}
+}
+
+// namespace - returns the namespace of the pod with the settings of its
+// namespace bootstrap policy
+func (d *podCustomDefaulter) namespace(ctx context.Context, name string) (*Namespace, error) {
+	namespace, err := d.GetNamespace(ctx, name)
+	if err != nil {
+		slog.Error("unable to get namespace", "error", err)
+		return nil, err
+	}
+
+	ns := Namespace{Namespace: namespace}
+	if d.tenantPolicy != nil {
+		policy, err := d.getPolicy(ctx, name)
+		if err != nil {
+			slog.Error("unable to get namespace bootstrap policy", "error", err)
+			return nil, err
+		}
+		ns.Settings = tenantSettings(policy, d.tenantPolicy, slog.Default())
+	}
+	return &ns, nil
+}
+
+// runDefaulters - returns true if any of the defaulters modified the pod
+func runDefaulters(ctx context.Context, defaulters []PodDefaulter, pod *corev1.Pod, ns *Namespace) (bool, error) {
 	var podDefaulted bool
-	for i, defaulter := range d.defaulters {
+	for i, defaulter := range defaulters {
 		kvals := keysAndValues(pod)
 		slog.Default().WithGroup("pod").With(kvals...).
-			WithGroup("ns").With("annotations", nsAnnotations).
+			WithGroup("ns").With("annotations", ns.Annotations, "labels", ns.Labels).
 			WithGroup("for").Debug("invoking defaulter",
 			"i", fmt.Sprintf("%d", i))
 
-		podModified, err := defaulter(pod, nsAnnotations)
+		podModified, err := defaulter(ctx, pod, ns)
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if !podModified {
@@ -150,17 +426,7 @@ func (d *podCustomDefaulter) Default(ctx context.Context, obj runtime.Object) (e
 
 		podDefaulted = true
 	}
-
-	if !podDefaulted {
-		return nil
-	}
-
-	if pod.Annotations == nil {
-		pod.Annotations = map[string]string{}
-	}
-	pod.Annotations[apiv1.AnnotationDefaulted] = "true"
-
-	return nil
+	return podDefaulted, nil
 }
 
 func keysAndValues(pod *corev1.Pod) []any {
@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
 	apiv1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -54,19 +55,23 @@ func getTestPod(annotations map[string]string) *corev1.Pod {
 var _ = Describe("Pod Webhook", func() {
 
 	Context("When creating Pod under Defaulting Webhook", func() {
-		nsf := apiv1.NamespaceFeatures{}
-		d1 := BuildPodDefaulterAddImagePullSecrets(testPullSecret, nsf)
-		d2 := BuildPodDefaulterAlterImgRegistry(map[string]string{
-			"test.com":      testRegistryName,
-			"test.com:2000": testRegistryName,
+		nsf, _ := apiv1.NewNamespaceFeatureMatcher(apiv1.NamespaceFeatures{}, nil, nil)
+		d1 := BuildPodDefaulterAlterImgRegistry(AlterImgRegistryOpts{
+			Rewriter: k8s.NewImageRewriter(map[string]string{
+				"test.com":      testRegistryName,
+				"test.com:2000": testRegistryName,
+			}, nil),
 		}, nsf)
+		d2 := BuildPodDefaulterAddImagePullSecrets([]apiv1.ImagePullSecret{
+			{Name: testPullSecret},
+		}, nil, nsf)
 
 		var defaulter = podCustomDefaulter{
-			defaulters: []func(*corev1.Pod, map[string]string) (bool, error){
+			defaulters: []PodDefaulter{
 				d1, d2,
 			},
-			GetNsAnnotations: func(_ context.Context, name string) (map[string]string, error) {
-				return nil, nil
+			GetNamespace: func(_ context.Context, name string) (*corev1.Namespace, error) {
+				return &corev1.Namespace{}, nil
 			},
 		}
 
@@ -108,13 +108,13 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	err = SetupPodWebhookWithManager(mgr, &apiv1.Config{
+	_, err = SetupPodWebhookWithManager(mgr, &apiv1.Config{
 		Overrides: map[string]string{
 			"replace.me": "ghcr.io",
 		},
 		ImagePullSecretName:      "test-me-plz",
 		ImagePullSecretNamespace: "kyma-system",
-	})
+	}, nil, nil)
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:webhook
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ConfigGroup            = "rt-bootstrapper.kyma-project.io"
+	ConfigKind             = "Config"
+	ConfigAPIVersionV1     = ConfigGroup + "/v1"
+	ConfigAPIVersionAlpha1 = ConfigGroup + "/v1alpha1"
+)
+
+// configDecoder - decodes the configuration document of a given version and
+// converts it into the internal configuration
+type configDecoder = func(data []byte) (*Config, error)
+
+var configDecoders = map[string]configDecoder{
+	ConfigAPIVersionV1:     decodeV1,
+	ConfigAPIVersionAlpha1: decodeV1Alpha1,
+}
+
+// typeMeta - identifies the version of the configuration document
+type typeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+// configV1 - the current version of the configuration document
+type configV1 struct {
+	typeMeta
+	Config
+}
+
+// configV1Alpha1 - the initial, unversioned configuration document; documents
+// without apiVersion and kind are decoded as v1alpha1
+type configV1Alpha1 struct {
+	typeMeta
+	Overrides                 map[string]string       `json:"overrides"`
+	ImagePullSecretName       string                  `json:"imagePullSecretName"`
+	ImagePullSecretNamespace  string                  `json:"imagePullSecretNamespace"`
+	SecretSyncInterval        Duration                `json:"secretSyncInterval"`
+	ClusterTrustBundleMapping *k8s.ClusterTrustBundle `json:"clusterTrustBundle,omitempty"`
+	NamespaceFeatures         *NamespaceFeatures      `json:"namespaceFeatures,omitempty"`
+}
+
+func (c configV1Alpha1) convert() *Config {
+	return &Config{
+		Overrides:                 c.Overrides,
+		ImagePullSecretName:       c.ImagePullSecretName,
+		ImagePullSecretNamespace:  c.ImagePullSecretNamespace,
+		SecretSyncInterval:        c.SecretSyncInterval,
+		ClusterTrustBundleMapping: c.ClusterTrustBundleMapping,
+		NamespaceFeatures:         c.NamespaceFeatures,
+	}
+}
+
+// decodeStrict - decodes the document and rejects unknown fields
+func decodeStrict(data []byte, out any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(out)
+}
+
+func decodeV1(data []byte) (*Config, error) {
+	var out configV1
+	if err := decodeStrict(data, &out); err != nil {
+		return nil, fmt.Errorf("unable to decode %s configuration: %w", ConfigAPIVersionV1, err)
+	}
+	return &out.Config, nil
+}
+
+func decodeV1Alpha1(data []byte) (*Config, error) {
+	var out configV1Alpha1
+	if err := decodeStrict(data, &out); err != nil {
+		return nil, fmt.Errorf("unable to decode %s configuration: %w", ConfigAPIVersionAlpha1, err)
+	}
+	return out.convert(), nil
+}
+
+func supportedAPIVersions() string {
+	result := make([]string, 0, len(configDecoders))
+	for version := range configDecoders {
+		result = append(result, version)
+	}
+	slices.Sort(result)
+	return strings.Join(result, ", ")
+}
+
+// isJSON - checks if the document is a JSON object; any other document is
+// considered to be YAML
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return bytes.HasPrefix(trimmed, []byte("{"))
+}
+
+// decodeConfig - dispatches the document to the decoder of its version; YAML
+// documents are converted to JSON first, so both formats are decoded the same way
+func decodeConfig(data []byte) (*Config, error) {
+	if !isJSON(data) {
+		converted, err := yaml.YAMLToJSONStrict(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode YAML configuration: %w", err)
+		}
+		data = converted
+	}
+
+	var meta typeMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+
+	// unversioned documents predate the versioned schema
+	if meta.APIVersion == "" && meta.Kind == "" {
+		return decodeV1Alpha1(data)
+	}
+
+	if meta.Kind != ConfigKind {
+		return nil, fmt.Errorf("unsupported kind '%s', expected '%s'", meta.Kind, ConfigKind)
+	}
+
+	decode, found := configDecoders[meta.APIVersion]
+	if !found {
+		return nil, fmt.Errorf("unsupported apiVersion '%s', supported versions: %s",
+			meta.APIVersion, supportedAPIVersions())
+	}
+
+	return decode(data)
+}
+
+// MarshalConfig - encodes the configuration as the current version of the
+// configuration document
+func MarshalConfig(cfg Config) ([]byte, error) {
+	return json.Marshal(configV1{
+		typeMeta: typeMeta{
+			APIVersion: ConfigAPIVersionV1,
+			Kind:       ConfigKind,
+		},
+		Config: cfg,
+	})
+}
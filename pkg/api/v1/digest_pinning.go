@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// DigestPinningKeepTag - the images that can not be resolved keep their
+	// tags, the default
+	DigestPinningKeepTag = "KeepTag"
+	// DigestPinningReject - the pods with images that can not be resolved are
+	// rejected
+	DigestPinningReject = "Reject"
+)
+
+var digestPinningPolicies = []string{DigestPinningKeepTag, DigestPinningReject}
+
+// DigestPinning - the resolution of the image tags to digests, applied to
+// the pods with the pin-img-digest feature enabled
+// +kubebuilder:object:generate=true
+type DigestPinning struct {
+	// OnFailure - handling of the images that can not be resolved, either
+	// 'KeepTag' (default) or 'Reject'
+	// +kubebuilder:validation:Enum=KeepTag;Reject
+	OnFailure     string `json:"onFailure,omitempty"`
+	RegistryQuery `json:",inline"`
+}
+
+// DigestPinningOrDefault - returns the digest pinning settings, the defaults if not
+// configured
+func (c *Config) DigestPinningOrDefault() *DigestPinning {
+	if c.DigestPinning == nil {
+		return &DigestPinning{}
+	}
+	return c.DigestPinning
+}
+
+// RejectUnresolved - returns true if the pods with images that can not be
+// resolved are rejected
+func (p *DigestPinning) RejectUnresolved() bool {
+	return p.OnFailure == DigestPinningReject
+}
+
+func (p *DigestPinning) validate(fldPath *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	if p.OnFailure != "" && !slices.Contains(digestPinningPolicies, p.OnFailure) {
+		result = append(result, field.NotSupported(fldPath.Child("onFailure"), p.OnFailure, digestPinningPolicies))
+	}
+
+	return append(result, p.RegistryQuery.validate(fldPath)...)
+}
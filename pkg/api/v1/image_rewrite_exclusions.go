@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// FeatureExcludeImagesPrefix - the prefix of the namespace features enabling
+// the named image rewrite exclusions, e.g.
+// 'rt-cfg.kyma-project.io/exclude-images.licensed' for the 'licensed' ones
+const FeatureExcludeImagesPrefix = "rt-cfg.kyma-project.io/exclude-images."
+
+// ImageExclusions - the images the overrides and the rewrite rules are never
+// applied to, e.g. the vendor images that must not be mirrored
+// +kubebuilder:object:generate=true
+type ImageExclusions struct {
+	// Images - the image names without a tag or a digest, e.g.
+	// 'registry.vendor.io/product/app'; all the tags of the images are
+	// excluded
+	Images []string `json:"images,omitempty"`
+	// Prefixes - the registries or the repository prefixes in the
+	// host[:port][/path] format, e.g. 'registry.vendor.io/product'
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Patterns - regular expressions that have to match the whole repository
+	// of the image including its registry, like the rewrite rules
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// ImageRewriteExclusions - the images excluded from the registry rewrite in
+// all namespaces and, additionally, in the selected ones
+// +kubebuilder:object:generate=true
+type ImageRewriteExclusions struct {
+	ImageExclusions `json:",inline"`
+	// Named - the additional exclusions of the namespaces with the
+	// 'rt-cfg.kyma-project.io/exclude-images.<name>' feature enabled by
+	// namespaceFeatures, namespaceFeatureRules or defaultFeatures
+	Named map[string]ImageExclusions `json:"named,omitempty"`
+}
+
+// ExcludeImagesFeature - returns the namespace feature enabling the named
+// image rewrite exclusions
+func ExcludeImagesFeature(name string) string {
+	return FeatureExcludeImagesPrefix + name
+}
+
+type compiledImageExclusions struct {
+	images   []k8s.ImageName
+	prefixes []string
+	patterns []string
+	regexes  []*regexp.Regexp
+}
+
+func (e ImageExclusions) compile() (compiledImageExclusions, error) {
+	result := compiledImageExclusions{
+		prefixes: e.Prefixes,
+		patterns: e.Patterns,
+	}
+
+	for _, image := range e.Images {
+		name, err := k8s.ParseImage(image)
+		if err != nil {
+			return result, err
+		}
+		result.images = append(result.images, name)
+	}
+
+	for _, pattern := range e.Patterns {
+		regex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return result, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		result.regexes = append(result.regexes, regex)
+	}
+
+	return result, nil
+}
+
+// excluded - returns the exclusion matching the image, empty if none matches
+func (e compiledImageExclusions) excluded(name k8s.ImageName) string {
+	if index := slices.IndexFunc(e.images, func(image k8s.ImageName) bool {
+		return image.Registry == name.Registry && image.Repository == name.Repository
+	}); index != -1 {
+		return e.images[index].Image
+	}
+
+	if index := slices.IndexFunc(e.prefixes, name.HasPrefix); index != -1 {
+		return e.prefixes[index]
+	}
+
+	repository := name.Registry + "/" + name.Repository
+	if index := slices.IndexFunc(e.regexes, func(regex *regexp.Regexp) bool {
+		return regex.MatchString(repository)
+	}); index != -1 {
+		return e.patterns[index]
+	}
+
+	return ""
+}
+
+// ImageExclusionMatcher - resolves if the image is excluded from the registry
+// rewrite in the namespace
+type ImageExclusionMatcher struct {
+	global compiledImageExclusions
+	named  map[string]compiledImageExclusions
+}
+
+// ImageExclusionMatcher - builds the matcher of the image rewrite exclusions,
+// nil if there are none
+func (c *Config) ImageExclusionMatcher() (*ImageExclusionMatcher, error) {
+	if c.ImageRewriteExclusions == nil {
+		return nil, nil
+	}
+
+	global, err := c.ImageRewriteExclusions.compile()
+	if err != nil {
+		return nil, fmt.Errorf("image rewrite exclusions: %w", err)
+	}
+
+	result := ImageExclusionMatcher{
+		global: global,
+		named:  make(map[string]compiledImageExclusions, len(c.ImageRewriteExclusions.Named)),
+	}
+
+	for name, exclusions := range c.ImageRewriteExclusions.Named {
+		compiled, err := exclusions.compile()
+		if err != nil {
+			return nil, fmt.Errorf("image rewrite exclusions '%s': %w", name, err)
+		}
+		result.named[name] = compiled
+	}
+
+	return &result, nil
+}
+
+// Excluded - returns the exclusion matching the image, empty if the image can
+// be rewritten; the named exclusions apply if their features are among the
+// namespace features resolved by the NamespaceFeatureMatcher
+func (m *ImageExclusionMatcher) Excluded(features map[string]string, name k8s.ImageName) string {
+	if m == nil {
+		return ""
+	}
+
+	if exclusion := m.global.excluded(name); exclusion != "" {
+		return exclusion
+	}
+
+	for _, feature := range slices.Sorted(maps.Keys(features)) {
+		exclusionsName, found := strings.CutPrefix(feature, FeatureExcludeImagesPrefix)
+		if !found || features[feature] != "true" {
+			continue
+		}
+		if exclusion := m.named[exclusionsName].excluded(name); exclusion != "" {
+			return exclusion
+		}
+	}
+	return ""
+}
+
+func (e ImageExclusions) validate(fldPath *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	for i, image := range e.Images {
+		imagePath := fldPath.Child("images").Index(i)
+
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			result = append(result, field.Invalid(imagePath, image, err.Error()))
+			continue
+		}
+		if !reference.IsNameOnly(named) {
+			result = append(result, field.Invalid(imagePath, image, "must not have a tag or a digest"))
+		}
+	}
+
+	for i, prefix := range e.Prefixes {
+		result = append(result, validateRepositoryPrefix(fldPath.Child("prefixes").Index(i), prefix)...)
+	}
+
+	for i, pattern := range e.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			result = append(result, field.Invalid(fldPath.Child("patterns").Index(i), pattern, err.Error()))
+		}
+	}
+
+	return result
+}
+
+func (e *ImageRewriteExclusions) validate(fldPath *field.Path) field.ErrorList {
+	result := e.ImageExclusions.validate(fldPath)
+
+	namedPath := fldPath.Child("named")
+	for _, name := range sortedKeys(e.Named) {
+		result = append(result, validateDNS(namedPath.Key(name), name, validation.IsDNS1123Label)...)
+		result = append(result, e.Named[name].validate(namedPath.Key(name))...)
+	}
+
+	return result
+}
+
+// validateNamespaceFeature - checks the feature enabled by namespaceFeatures,
+// namespaceFeatureRules or defaultFeatures; besides the known features, they
+// enable the defined named image rewrite exclusions
+func validateNamespaceFeature(fldPath *field.Path, feature string, exclusions *ImageRewriteExclusions) field.ErrorList {
+	name, found := strings.CutPrefix(feature, FeatureExcludeImagesPrefix)
+	if !found {
+		return validateFeature(fldPath, feature)
+	}
+
+	if exclusions != nil {
+		if _, defined := exclusions.Named[name]; defined {
+			return nil
+		}
+	}
+	return field.ErrorList{field.Invalid(fldPath, feature,
+		fmt.Sprintf("image rewrite exclusions '%s' not defined in imageRewriteExclusions.named", name))}
+}
@@ -0,0 +1,129 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfig_ImageExclusionMatcher(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imageRewriteExclusions:
+  images: [registry.vendor.io/product/app, nginx]
+  prefixes: [quay.io/licensed]
+  patterns: ['gcr\.io/.*-enterprise']
+  named:
+    team-a:
+      prefixes: [ghcr.io/team-a]
+    licensed:
+      images: [ghcr.io/vendor/app]
+namespaceFeatures:
+  team-a: [rt-cfg.kyma-project.io/exclude-images.team-a]
+namespaceFeatureRules:
+- nameRegex: 'vendor-.+'
+  selector:
+    matchLabels:
+      licensed: "true"
+  features: [rt-cfg.kyma-project.io/exclude-images.licensed]
+`
+
+	cfg, err := v1.NewConfig(strings.NewReader(val))
+	require.NoError(t, err)
+
+	matcher, err := cfg.ImageExclusionMatcher()
+	require.NoError(t, err)
+
+	nsf, err := cfg.NamespaceFeatureMatcher()
+	require.NoError(t, err)
+
+	newNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	licensed := map[string]string{"licensed": "true"}
+
+	tcs := []struct {
+		image     string
+		namespace *corev1.Namespace
+		expected  string
+	}{
+		{image: "registry.vendor.io/product/app:v1", expected: "registry.vendor.io/product/app"},
+		{image: "registry.vendor.io/product/app-tools:v1"},
+		{image: "docker.io/library/nginx:1.27", expected: "nginx"},
+		{image: "quay.io/licensed/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", expected: "quay.io/licensed"},
+		{image: "quay.io/licensed-other/app:v1"},
+		{image: "gcr.io/project/db-enterprise:v1", expected: `gcr\.io/.*-enterprise`},
+		{image: "ghcr.io/team-a/app:v1"},
+		{image: "ghcr.io/team-a/app:v1", namespace: newNamespace("team-a", nil), expected: "ghcr.io/team-a"},
+		{image: "ghcr.io/team-a/app:v1", namespace: newNamespace("team-b", nil)},
+		{image: "nginx", namespace: newNamespace("team-a", nil), expected: "nginx"},
+		{image: "ghcr.io/vendor/app:v1", namespace: newNamespace("vendor-a", licensed), expected: "ghcr.io/vendor/app"},
+		{image: "ghcr.io/vendor/app:v1", namespace: newNamespace("vendor-a", nil)},
+		{image: "ghcr.io/vendor/app:v1", namespace: newNamespace("other", licensed)},
+		// the namespaceFeatures entry takes precedence over the rules
+		{image: "ghcr.io/vendor/app:v1", namespace: newNamespace("team-a", licensed)},
+	}
+
+	for _, tc := range tcs {
+		var nsName string
+		if tc.namespace != nil {
+			nsName = tc.namespace.Name
+		}
+
+		t.Run(tc.image+" in '"+nsName+"'", func(t *testing.T) {
+			name, err := k8s.ParseImage(tc.image)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, matcher.Excluded(nsf.Features(tc.namespace), name))
+		})
+	}
+}
+
+func TestNewConfig_invalidImageRewriteExclusions(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imageRewriteExclusions:
+  images: [registry.vendor.io/product/app:v1, registry.vendor.io/Product/app]
+  prefixes: [quay.io/Licensed]
+  patterns: ['gcr\.io/(']
+  named:
+    team_a:
+      images: [nginx@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855]
+namespaceFeatures:
+  team-a: [rt-cfg.kyma-project.io/exclude-images.team-b]
+namespaceFeatureRules:
+- names: [vendor-*]
+  features: [rt-cfg.kyma-project.io/exclude-images.licensed]
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imageRewriteExclusions.images[0]: Invalid value: "registry.vendor.io/product/app:v1": must not have a tag or a digest`,
+		`imageRewriteExclusions.images[1]: Invalid value: "registry.vendor.io/Product/app"`,
+		`imageRewriteExclusions.prefixes[0]: Invalid value: "quay.io/Licensed"`,
+		`imageRewriteExclusions.patterns[0]: Invalid value: "gcr\\.io/("`,
+		`imageRewriteExclusions.named[team_a]: Invalid value: "team_a"`,
+		`imageRewriteExclusions.named[team_a].images[0]: Invalid value: "nginx@sha256:`,
+		`namespaceFeatures[team-a][0]: Invalid value: "rt-cfg.kyma-project.io/exclude-images.team-b": image rewrite exclusions 'team-b' not defined`,
+		`namespaceFeatureRules[0].features[0]: Invalid value: "rt-cfg.kyma-project.io/exclude-images.licensed"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
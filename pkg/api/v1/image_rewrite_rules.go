@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// InvalidImageReject - the pods with malformed image references are
+	// rejected, the default
+	InvalidImageReject = "Reject"
+	// InvalidImageSkip - the malformed image references are logged and left
+	// unchanged
+	InvalidImageSkip = "Skip"
+)
+
+var invalidImagePolicies = []string{InvalidImageReject, InvalidImageSkip}
+
+// ImageRewriteRule - rewrites the image repositories matching the regular
+// expression; the rules are evaluated in the configuration order after the
+// overrides and the first matching rule wins
+type ImageRewriteRule struct {
+	// Name - identifies the rule in the logs, defaults to its position in the
+	// list, e.g. 'imageRewriteRules[0]'
+	Name string `json:"name,omitempty"`
+	// Match - regular expression that has to match the whole repository of the
+	// image including its registry, e.g. 'quay\.io/([^/]+)/([^/]+)'
+	Match string `json:"match" validate:"required"`
+	// Replacement - the new repository, can refer to the capture groups of
+	// the expression, e.g. 'mirror.local/quay-$1-$2'
+	Replacement string `json:"replacement" validate:"required"`
+}
+
+func (r ImageRewriteRule) compile(i int) (k8s.ImageRewriteRule, error) {
+	name := r.Name
+	if name == "" {
+		name = fmt.Sprintf("imageRewriteRules[%d]", i)
+	}
+
+	regex, err := regexp.Compile("^(?:" + r.Match + ")$")
+	if err != nil {
+		return k8s.ImageRewriteRule{}, fmt.Errorf("image rewrite rule '%s': invalid regex: %w", name, err)
+	}
+
+	return k8s.ImageRewriteRule{
+		Name:        name,
+		Regex:       regex,
+		Replacement: r.Replacement,
+	}, nil
+}
+
+// ImageRewriter - builds the rewriter of the container images
+func (c *Config) ImageRewriter() (*k8s.ImageRewriter, error) {
+	rules := make([]k8s.ImageRewriteRule, 0, len(c.ImageRewriteRules))
+	for i, rule := range c.ImageRewriteRules {
+		compiled, err := rule.compile(i)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, compiled)
+	}
+	return k8s.NewImageRewriter(c.Overrides, rules), nil
+}
+
+// SkipInvalidImages - returns true if the malformed image references are
+// skipped instead of rejected
+func (c *Config) SkipInvalidImages() bool {
+	return c.InvalidImagePolicy == InvalidImageSkip
+}
+
+func validateInvalidImagePolicy(fldPath *field.Path, policy string) field.ErrorList {
+	if policy == "" || slices.Contains(invalidImagePolicies, policy) {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fldPath, policy, invalidImagePolicies)}
+}
+
+// validateImageAnnotations - the keys have to be valid annotation keys
+func validateImageAnnotations(fldPath *field.Path, keys []string) field.ErrorList {
+	var result field.ErrorList
+
+	seen := sets.New[string]()
+	for i, key := range keys {
+		if seen.Has(key) {
+			result = append(result, field.Duplicate(fldPath.Index(i), key))
+		}
+		seen.Insert(key)
+
+		for _, msg := range validation.IsQualifiedName(key) {
+			result = append(result, field.Invalid(fldPath.Index(i), key, msg))
+		}
+	}
+	return result
+}
+
+func validateImageRewriteRules(fldPath *field.Path, rules []ImageRewriteRule) field.ErrorList {
+	var result field.ErrorList
+
+	names := sets.New[string]()
+	for i, rule := range rules {
+		rulePath := fldPath.Index(i)
+
+		if rule.Name != "" {
+			if names.Has(rule.Name) {
+				result = append(result, field.Duplicate(rulePath.Child("name"), rule.Name))
+			}
+			names.Insert(rule.Name)
+		}
+
+		if rule.Match == "" {
+			// reported by the structural validation
+			continue
+		}
+
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			result = append(result, field.Invalid(rulePath.Child("match"), rule.Match, err.Error()))
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig_imageRewriteRules(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imageRewriteRules:
+- name: quay
+  match: quay\.io/([^/]+)/([^/]+)
+  replacement: mirror.local/quay-$1-$2
+- match: ghcr\.io/(.+)
+  replacement: mirror.local/ghcr/$1
+`
+
+	cfg, err := v1.NewConfig(strings.NewReader(val))
+	require.NoError(t, err)
+
+	rewriter, err := cfg.ImageRewriter()
+	require.NoError(t, err)
+
+	for image, expected := range map[string]string{
+		"quay.io/org/img:v1":       "quay.io/org/img:v1: quay -> mirror.local/quay-org-img:v1",
+		"ghcr.io/org/team/img:v2":  "ghcr.io/org/team/img:v2: imageRewriteRules[1] -> mirror.local/ghcr/org/team/img:v2",
+		"my-quay.io/org/img:v1":    "my-quay.io/org/img:v1: no rule matched",
+		"quay.io/org/team/img:v1":  "quay.io/org/team/img:v1: no rule matched",
+		"docker.io/library/ubuntu": "docker.io/library/ubuntu: no rule matched",
+	} {
+		rewrite, err := rewriter.Explain(image)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rewrite.String())
+	}
+}
+
+func TestNewConfig_invalidImageRewriteRules(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imageRewriteRules:
+- name: quay
+  match: quay\.io/(
+  replacement: mirror.local/quay
+- name: quay
+  match: quay\.io/(.+)
+  replacement: mirror.local/quay/$1
+- replacement: mirror.local
+invalidImagePolicy: Ignore
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imageRewriteRules[0].match: Invalid value: "quay\\.io/("`,
+		`imageRewriteRules[1].name: Duplicate value: "quay"`,
+		`imageRewriteRules[2].match: Required value`,
+		`invalidImagePolicy: Unsupported value: "Ignore"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestNewConfig_invalidImageAnnotations(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imageAnnotations:
+- sidecar.istio.io/proxyImage
+- sidecar.istio.io/proxyImage
+- sidecar.istio.io/proxy/image
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imageAnnotations[1]: Duplicate value: "sidecar.istio.io/proxyImage"`,
+		`imageAnnotations[2]: Invalid value: "sidecar.istio.io/proxy/image"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// MirrorCheckKeepOriginal - the images missing in the mirror are left
+	// unchanged, the default
+	MirrorCheckKeepOriginal = "KeepOriginal"
+	// MirrorCheckReject - the pods with images missing in the mirror are
+	// rejected
+	MirrorCheckReject = "Reject"
+
+	defaultRegistryQueryCacheTTL = 5 * time.Minute
+	defaultRegistryQueryTimeout  = 3 * time.Second
+)
+
+var mirrorCheckPolicies = []string{MirrorCheckKeepOriginal, MirrorCheckReject}
+
+// RegistryQuery - how the registries are queried for the image manifests;
+// the registries are queried with the credentials of the master pull secrets
+// +kubebuilder:object:generate=true
+type RegistryQuery struct {
+	// CacheTTL - how long the results of the queries are cached, defaults to
+	// 5m
+	CacheTTL Duration `json:"cacheTTL,omitempty"`
+	// Timeout - the timeout of a single query, defaults to 3s; the queries
+	// are also limited by the timeout of the webhook
+	Timeout Duration `json:"timeout,omitempty"`
+	// PlainHTTPRegistries - the registries queried over HTTP instead of HTTPS
+	PlainHTTPRegistries []string `json:"plainHTTPRegistries,omitempty"`
+}
+
+// CacheTTLOrDefault - returns the cache TTL or its default if not set
+func (q *RegistryQuery) CacheTTLOrDefault() time.Duration {
+	if q.CacheTTL == 0 {
+		return defaultRegistryQueryCacheTTL
+	}
+	return time.Duration(q.CacheTTL)
+}
+
+// TimeoutOrDefault - returns the timeout or its default if not set
+func (q *RegistryQuery) TimeoutOrDefault() time.Duration {
+	if q.Timeout == 0 {
+		return defaultRegistryQueryTimeout
+	}
+	return time.Duration(q.Timeout)
+}
+
+func (q *RegistryQuery) validate(fldPath *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	if q.CacheTTL < 0 {
+		result = append(result, field.Invalid(fldPath.Child("cacheTTL"),
+			time.Duration(q.CacheTTL).String(), "must be greater than zero"))
+	}
+
+	if q.Timeout < 0 {
+		result = append(result, field.Invalid(fldPath.Child("timeout"),
+			time.Duration(q.Timeout).String(), "must be greater than zero"))
+	}
+
+	for i, registry := range q.PlainHTTPRegistries {
+		result = append(result, validateRegistry(fldPath.Child("plainHTTPRegistries").Index(i), registry)...)
+	}
+	return result
+}
+
+// MirrorCheck - verifies the rewritten images exist in the mirror before the
+// pods are changed
+// +kubebuilder:object:generate=true
+type MirrorCheck struct {
+	// OnMissing - handling of the images missing in the mirror, either
+	// 'KeepOriginal' (default) or 'Reject'; the mirrors that can not be
+	// queried are handled the same way
+	// +kubebuilder:validation:Enum=KeepOriginal;Reject
+	OnMissing     string `json:"onMissing,omitempty"`
+	RegistryQuery `json:",inline"`
+}
+
+// RejectMissing - returns true if the pods with images missing in the mirror
+// are rejected
+func (m *MirrorCheck) RejectMissing() bool {
+	return m.OnMissing == MirrorCheckReject
+}
+
+func (m *MirrorCheck) validate(fldPath *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	if m.OnMissing != "" && !slices.Contains(mirrorCheckPolicies, m.OnMissing) {
+		result = append(result, field.NotSupported(fldPath.Child("onMissing"), m.OnMissing, mirrorCheckPolicies))
+	}
+
+	return append(result, m.RegistryQuery.validate(fldPath)...)
+}
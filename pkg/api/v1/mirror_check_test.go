@@ -0,0 +1,63 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig_mirrorCheck(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+mirrorCheck:
+  onMissing: Reject
+  cacheTTL: 1m
+  plainHTTPRegistries: ["mirror.local:5000"]
+`
+
+	cfg, err := v1.NewConfig(strings.NewReader(val))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.MirrorCheck)
+
+	assert.True(t, cfg.MirrorCheck.RejectMissing())
+	assert.Equal(t, time.Minute, cfg.MirrorCheck.CacheTTLOrDefault())
+	assert.Equal(t, 3*time.Second, cfg.MirrorCheck.TimeoutOrDefault())
+	assert.Equal(t, []string{"mirror.local:5000"}, cfg.MirrorCheck.PlainHTTPRegistries)
+}
+
+func TestNewConfig_invalidMirrorCheck(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+mirrorCheck:
+  onMissing: Ignore
+  cacheTTL: -1m
+  timeout: -1s
+  plainHTTPRegistries: ["mirror.local/path"]
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`mirrorCheck.onMissing: Unsupported value: "Ignore"`,
+		`mirrorCheck.cacheTTL: Invalid value: "-1m0s"`,
+		`mirrorCheck.timeout: Invalid value: "-1s"`,
+		`mirrorCheck.plainHTTPRegistries[0]: Invalid value: "mirror.local/path"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
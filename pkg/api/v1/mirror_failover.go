@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	defaultMirrorProbeInterval         = 10 * time.Second
+	defaultMirrorProbeTimeout          = 2 * time.Second
+	defaultMirrorProbeFailureThreshold = 3
+	defaultMirrorProbeSuccessThreshold = 1
+)
+
+// MirrorFailover - the fallback mirrors of the overrides used while the
+// target of the override fails the health probes
+// +kubebuilder:object:generate=true
+type MirrorFailover struct {
+	// Fallbacks - the fallback mirrors in the host[:port][/path] format in
+	// the priority order, keyed by the override key
+	Fallbacks map[string][]string `json:"fallbacks"`
+	// Interval - the time between the health probes of a mirror, defaults to
+	// 10s
+	Interval Duration `json:"interval,omitempty"`
+	// Timeout - the timeout of a single health probe, defaults to 2s
+	Timeout Duration `json:"timeout,omitempty"`
+	// FailureThreshold - the number of the consecutive failed probes a
+	// mirror becomes unhealthy after, defaults to 3
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// SuccessThreshold - the number of the consecutive successful probes an
+	// unhealthy mirror becomes healthy after, defaults to 1
+	SuccessThreshold int `json:"successThreshold,omitempty"`
+	// PlainHTTPRegistries - the mirrors probed over HTTP instead of HTTPS
+	PlainHTTPRegistries []string `json:"plainHTTPRegistries,omitempty"`
+}
+
+// IntervalOrDefault - returns the probe interval or its default if not set
+func (f *MirrorFailover) IntervalOrDefault() time.Duration {
+	if f.Interval == 0 {
+		return defaultMirrorProbeInterval
+	}
+	return time.Duration(f.Interval)
+}
+
+// TimeoutOrDefault - returns the probe timeout or its default if not set
+func (f *MirrorFailover) TimeoutOrDefault() time.Duration {
+	if f.Timeout == 0 {
+		return defaultMirrorProbeTimeout
+	}
+	return time.Duration(f.Timeout)
+}
+
+// FailureThresholdOrDefault - returns the failure threshold or its default
+// if not set
+func (f *MirrorFailover) FailureThresholdOrDefault() int {
+	if f.FailureThreshold == 0 {
+		return defaultMirrorProbeFailureThreshold
+	}
+	return f.FailureThreshold
+}
+
+// SuccessThresholdOrDefault - returns the success threshold or its default
+// if not set
+func (f *MirrorFailover) SuccessThresholdOrDefault() int {
+	if f.SuccessThreshold == 0 {
+		return defaultMirrorProbeSuccessThreshold
+	}
+	return f.SuccessThreshold
+}
+
+// ProbedMirrors - returns the sorted hosts in the host[:port] format of the
+// targets and the fallback mirrors of the overrides with fallbacks
+func (c *Config) ProbedMirrors() []string {
+	if c.MirrorFailover == nil {
+		return nil
+	}
+
+	var result []string
+	for key, fallbacks := range c.MirrorFailover.Fallbacks {
+		target, found := c.Overrides[key]
+		if !found || len(fallbacks) == 0 {
+			continue
+		}
+
+		for _, mirror := range append([]string{target}, fallbacks...) {
+			host, _, _ := strings.Cut(mirror, "/")
+			result = append(result, host)
+		}
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+func (f *MirrorFailover) validate(fldPath *field.Path, overrides map[string]string) field.ErrorList {
+	var result field.ErrorList
+
+	fallbacksPath := fldPath.Child("fallbacks")
+	for _, key := range sortedKeys(f.Fallbacks) {
+		if _, found := overrides[key]; !found {
+			result = append(result, field.Invalid(fallbacksPath.Key(key), key,
+				"must be a key of the overrides"))
+		}
+
+		for i, mirror := range f.Fallbacks[key] {
+			result = append(result, validateRepositoryPrefix(fallbacksPath.Key(key).Index(i), mirror)...)
+		}
+	}
+
+	if f.Interval < 0 {
+		result = append(result, field.Invalid(fldPath.Child("interval"),
+			time.Duration(f.Interval).String(), "must be greater than zero"))
+	}
+
+	if f.Timeout < 0 {
+		result = append(result, field.Invalid(fldPath.Child("timeout"),
+			time.Duration(f.Timeout).String(), "must be greater than zero"))
+	}
+
+	if f.FailureThreshold < 0 {
+		result = append(result, field.Invalid(fldPath.Child("failureThreshold"),
+			f.FailureThreshold, "must be greater than zero"))
+	}
+
+	if f.SuccessThreshold < 0 {
+		result = append(result, field.Invalid(fldPath.Child("successThreshold"),
+			f.SuccessThreshold, "must be greater than zero"))
+	}
+
+	for i, registry := range f.PlainHTTPRegistries {
+		result = append(result, validateRegistry(fldPath.Child("plainHTTPRegistries").Index(i), registry)...)
+	}
+	return result
+}
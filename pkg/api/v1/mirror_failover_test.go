@@ -0,0 +1,79 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig_mirrorFailover(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides:
+  gcr.io: mirror-a.local/gcr
+  quay.io: mirror-a.local/quay
+  docker.io: mirror-c.local
+mirrorFailover:
+  fallbacks:
+    gcr.io: ["mirror-b.local:5000/gcr"]
+    quay.io: ["mirror-b.local:5000/quay"]
+  interval: 30s
+  failureThreshold: 2
+  plainHTTPRegistries: ["mirror-b.local:5000"]
+`
+
+	cfg, err := v1.NewConfig(strings.NewReader(val))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.MirrorFailover)
+
+	assert.Equal(t, 30*time.Second, cfg.MirrorFailover.IntervalOrDefault())
+	assert.Equal(t, 2*time.Second, cfg.MirrorFailover.TimeoutOrDefault())
+	assert.Equal(t, 2, cfg.MirrorFailover.FailureThresholdOrDefault())
+	assert.Equal(t, 1, cfg.MirrorFailover.SuccessThresholdOrDefault())
+	// the targets of the overrides without fallbacks are not probed
+	assert.Equal(t, []string{"mirror-a.local", "mirror-b.local:5000"}, cfg.ProbedMirrors())
+}
+
+func TestNewConfig_invalidMirrorFailover(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides:
+  gcr.io: mirror-a.local/gcr
+mirrorFailover:
+  fallbacks:
+    gcr.io: ["Mirror-b.local"]
+    quay.io: ["mirror-b.local"]
+  interval: -1s
+  timeout: -1s
+  failureThreshold: -1
+  successThreshold: -1
+  plainHTTPRegistries: ["mirror.local/path"]
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`mirrorFailover.fallbacks[gcr.io][0]: Invalid value: "Mirror-b.local"`,
+		`mirrorFailover.fallbacks[quay.io]: Invalid value: "quay.io": must be a key of the overrides`,
+		`mirrorFailover.interval: Invalid value: "-1s"`,
+		`mirrorFailover.timeout: Invalid value: "-1s"`,
+		`mirrorFailover.failureThreshold: Invalid value: -1`,
+		`mirrorFailover.successThreshold: Invalid value: -1`,
+		`mirrorFailover.plainHTTPRegistries[0]: Invalid value: "mirror.local/path"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// NamespaceFeatureRule - enables the features for all namespaces matching the
+// rule; all the criteria set in the rule have to match
+// +kubebuilder:object:generate=true
+type NamespaceFeatureRule struct {
+	// Names - glob patterns (for example 'kyma-*'); the rule matches if any
+	// of them matches the namespace name
+	Names []string `json:"names,omitempty"`
+	// NameRegex - regular expression that has to match the whole namespace name
+	NameRegex string `json:"nameRegex,omitempty"`
+	// Selector - label selector evaluated against the namespace labels
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Features - features enabled for the matching namespaces in addition to
+	// the cluster-wide default features
+	Features []string `json:"features"`
+}
+
+// namespaceMatcher - the compiled namespace criteria shared by the rules
+// selecting namespaces; all the criteria set have to match
+type namespaceMatcher struct {
+	names    []string
+	regex    *regexp.Regexp
+	selector labels.Selector
+}
+
+func (m namespaceMatcher) matches(ns *corev1.Namespace) bool {
+	if len(m.names) > 0 && !slices.ContainsFunc(m.names, func(pattern string) bool {
+		// patterns are validated when the matcher is compiled
+		matched, _ := path.Match(pattern, ns.Name)
+		return matched
+	}) {
+		return false
+	}
+
+	if m.regex != nil && !m.regex.MatchString(ns.Name) {
+		return false
+	}
+
+	if m.selector != nil && !m.selector.Matches(labels.Set(ns.Labels)) {
+		return false
+	}
+
+	return true
+}
+
+func compileNamespaceMatcher(names []string, nameRegex string, selector *metav1.LabelSelector) (namespaceMatcher, error) {
+	result := namespaceMatcher{
+		names: names,
+	}
+
+	for _, pattern := range names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return result, fmt.Errorf("invalid name pattern '%s': %w", pattern, err)
+		}
+	}
+
+	if nameRegex != "" {
+		regex, err := regexp.Compile("^(?:" + nameRegex + ")$")
+		if err != nil {
+			return result, fmt.Errorf("invalid name regex: %w", err)
+		}
+		result.regex = regex
+	}
+
+	if selector != nil {
+		compiled, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return result, fmt.Errorf("invalid selector: %w", err)
+		}
+		result.selector = compiled
+	}
+
+	return result, nil
+}
+
+func validateNamespaceMatcher(
+	fldPath *field.Path,
+	names []string,
+	nameRegex string,
+	selector *metav1.LabelSelector) field.ErrorList {
+
+	var result field.ErrorList
+
+	if len(names) == 0 && nameRegex == "" && selector == nil {
+		result = append(result, field.Required(fldPath,
+			"at least one of names, nameRegex or selector is required"))
+	}
+
+	for i, pattern := range names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			result = append(result, field.Invalid(fldPath.Child("names").Index(i), pattern, err.Error()))
+		}
+	}
+
+	if nameRegex != "" {
+		if _, err := regexp.Compile(nameRegex); err != nil {
+			result = append(result, field.Invalid(fldPath.Child("nameRegex"), nameRegex, err.Error()))
+		}
+	}
+
+	if selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			result = append(result, field.Invalid(fldPath.Child("selector"), selector, err.Error()))
+		}
+	}
+
+	return result
+}
+
+type compiledNamespaceFeatureRule struct {
+	namespaceMatcher
+	features []string
+}
+
+func (r NamespaceFeatureRule) compile() (compiledNamespaceFeatureRule, error) {
+	matcher, err := compileNamespaceMatcher(r.Names, r.NameRegex, r.Selector)
+	if err != nil {
+		return compiledNamespaceFeatureRule{}, err
+	}
+
+	return compiledNamespaceFeatureRule{
+		namespaceMatcher: matcher,
+		features:         r.Features,
+	}, nil
+}
+
+func (r NamespaceFeatureRule) validate(fldPath *field.Path, exclusions *ImageRewriteExclusions) field.ErrorList {
+	result := validateNamespaceMatcher(fldPath, r.Names, r.NameRegex, r.Selector)
+
+	for i, feature := range r.Features {
+		result = append(result, validateNamespaceFeature(fldPath.Child("features").Index(i), feature, exclusions)...)
+	}
+
+	return result
+}
+
+// NamespaceFeatureMatcher - resolves the default features of a namespace.
+//
+// The cluster-wide default features apply to all namespaces. The features of
+// the exact namespaceFeatures entry of the namespace are enabled on top of
+// them; the rules are consulted only for the namespaces without an entry, and
+// the first rule (in the configuration order) matching the namespace wins, so
+// more specific rules have to be listed first. The features are opted out by
+// the "false" annotations of the namespace or the pod.
+type NamespaceFeatureMatcher struct {
+	exact    NamespaceFeatures
+	rules    []compiledNamespaceFeatureRule
+	defaults []string
+}
+
+func NewNamespaceFeatureMatcher(
+	exact NamespaceFeatures,
+	rules []NamespaceFeatureRule,
+	defaults []string) (*NamespaceFeatureMatcher, error) {
+
+	result := NamespaceFeatureMatcher{
+		exact:    exact,
+		rules:    make([]compiledNamespaceFeatureRule, 0, len(rules)),
+		defaults: defaults,
+	}
+
+	for i, rule := range rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("namespace feature rule %d: %w", i, err)
+		}
+		result.rules = append(result.rules, compiled)
+	}
+
+	return &result, nil
+}
+
+// NamespaceFeatureMatcher - builds the matcher of the namespace default features
+func (c *Config) NamespaceFeatureMatcher() (*NamespaceFeatureMatcher, error) {
+	var exact NamespaceFeatures
+	if c.NamespaceFeatures != nil {
+		exact = *c.NamespaceFeatures
+	}
+	return NewNamespaceFeatureMatcher(exact, c.NamespaceFeatureRules, c.DefaultFeatures)
+}
+
+// Features - returns the default features of the namespace
+func (m *NamespaceFeatureMatcher) Features(ns *corev1.Namespace) map[string]string {
+	if m == nil || ns == nil {
+		return map[string]string{}
+	}
+
+	result := enabledFeatures(m.defaults)
+
+	if _, found := m.exact[ns.Name]; found {
+		maps.Copy(result, m.exact.Features(ns.Name))
+		return result
+	}
+
+	for _, rule := range m.rules {
+		if !rule.matches(ns) {
+			continue
+		}
+		maps.Copy(result, enabledFeatures(rule.features))
+		break
+	}
+
+	return result
+}
+
+func enabledFeatures(features []string) map[string]string {
+	result := make(map[string]string, len(features))
+	for _, feature := range features {
+		result[feature] = "true"
+	}
+	return result
+}
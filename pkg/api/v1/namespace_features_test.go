@@ -0,0 +1,153 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceFeatureMatcher_Features(t *testing.T) {
+	exact := v1.NamespaceFeatures{
+		"kyma-system": {v1.AnnotationPinImgDigest},
+		"kyma-public": {},
+	}
+
+	rules := []v1.NamespaceFeatureRule{
+		{
+			// exception listed before the generic rule
+			Names:    []string{"kyma-legacy"},
+			Features: []string{},
+		},
+		{
+			Names:    []string{"kyma-*", "istio-*"},
+			Features: []string{v1.AnnotationAlterImgRegistry},
+		},
+		{
+			NameRegex: "team-[0-9]+",
+			Features:  []string{v1.AnnotationSetPullSecret},
+		},
+		{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tenant": "foo"},
+			},
+			Features: []string{v1.AnnotationAddClusterTrustBundle},
+		},
+	}
+
+	matcher, err := v1.NewNamespaceFeatureMatcher(exact, rules, []string{v1.AnnotationSetFipsMode})
+	require.NoError(t, err)
+
+	newNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+	}
+
+	tcs := []struct {
+		name     string
+		ns       *corev1.Namespace
+		expected map[string]string
+	}{
+		{
+			name: "exact entry takes precedence over rules",
+			ns:   newNamespace("kyma-system", nil),
+			expected: map[string]string{
+				v1.AnnotationPinImgDigest: "true",
+				v1.AnnotationSetFipsMode:  "true",
+			},
+		},
+		{
+			name:     "empty exact entry keeps the cluster-wide defaults",
+			ns:       newNamespace("kyma-public", nil),
+			expected: map[string]string{v1.AnnotationSetFipsMode: "true"},
+		},
+		{
+			name: "glob pattern",
+			ns:   newNamespace("istio-system", nil),
+			expected: map[string]string{
+				v1.AnnotationAlterImgRegistry: "true",
+				v1.AnnotationSetFipsMode:      "true",
+			},
+		},
+		{
+			name:     "first matching rule wins",
+			ns:       newNamespace("kyma-legacy", nil),
+			expected: map[string]string{v1.AnnotationSetFipsMode: "true"},
+		},
+		{
+			name: "regular expression matches whole name",
+			ns:   newNamespace("team-42", nil),
+			expected: map[string]string{
+				v1.AnnotationSetPullSecret: "true",
+				v1.AnnotationSetFipsMode:   "true",
+			},
+		},
+		{
+			name:     "regular expression does not match partially",
+			ns:       newNamespace("my-team-42-ns", nil),
+			expected: map[string]string{v1.AnnotationSetFipsMode: "true"},
+		},
+		{
+			name: "label selector",
+			ns:   newNamespace("customer", map[string]string{"tenant": "foo"}),
+			expected: map[string]string{
+				v1.AnnotationAddClusterTrustBundle: "true",
+				v1.AnnotationSetFipsMode:           "true",
+			},
+		},
+		{
+			name:     "cluster-wide defaults apply if nothing matches",
+			ns:       newNamespace("customer", map[string]string{"tenant": "bar"}),
+			expected: map[string]string{v1.AnnotationSetFipsMode: "true"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matcher.Features(tc.ns))
+		})
+	}
+}
+
+func TestNewConfig_namespaceFeatureRules(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+namespaceFeatureRules:
+- names: ["kyma-["]
+  features: [rt-cfg.kyma-project.io/alter-img-registry]
+- nameRegex: "team-("
+  features: []
+- selector:
+    matchExpressions:
+    - key: tenant
+      operator: Invalid
+  features: [rt-cfg.kyma-project.io/unknown]
+- features: []
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`namespaceFeatureRules[0].names[0]: Invalid value: "kyma-["`,
+		`namespaceFeatureRules[1].nameRegex: Invalid value: "team-("`,
+		`namespaceFeatureRules[2].selector: Invalid value`,
+		`namespaceFeatureRules[2].features[0]: Unsupported value: "rt-cfg.kyma-project.io/unknown"`,
+		`namespaceFeatureRules[3]: Required value`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
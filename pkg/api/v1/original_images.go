@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AnnotationOriginalImages - the images of the containers before the
+	// registry rewrite, a JSON map keyed by the container name
+	AnnotationOriginalImages = "rt-bootstrapper.kyma-project.io/original-images"
+	// AnnotationOriginalTags - the tags of the images pinned to digests, a
+	// JSON map keyed by the container name
+	AnnotationOriginalTags = "rt-bootstrapper.kyma-project.io/original-tags"
+	// AnnotationOriginalAnnotations - the images of the image-bearing
+	// annotations before the registry rewrite, a JSON map keyed by the
+	// annotation key
+	AnnotationOriginalAnnotations = "rt-bootstrapper.kyma-project.io/original-annotations"
+	// AnnotationOriginalVolumeImages - the images of the image volumes before
+	// the registry rewrite, a JSON map keyed by the volume name
+	AnnotationOriginalVolumeImages = "rt-bootstrapper.kyma-project.io/original-volume-images"
+	// AnnotationOriginalPullPolicies - the pull policies of the containers
+	// before they were set by the pull policy rules, a JSON map keyed by the
+	// container name
+	AnnotationOriginalPullPolicies = "rt-bootstrapper.kyma-project.io/original-pull-policies"
+
+	// AnnotationRewrittenImages - the images of the containers after the
+	// registry rewrite, a JSON map keyed by the container name; the webhook
+	// invoked again skips the images still equal to them
+	AnnotationRewrittenImages = "rt-bootstrapper.kyma-project.io/rewritten-images"
+	// AnnotationRewrittenAnnotations - the images of the image-bearing
+	// annotations after the registry rewrite, a JSON map keyed by the
+	// annotation key
+	AnnotationRewrittenAnnotations = "rt-bootstrapper.kyma-project.io/rewritten-annotations"
+	// AnnotationRewrittenVolumeImages - the images of the image volumes after
+	// the registry rewrite, a JSON map keyed by the volume name
+	AnnotationRewrittenVolumeImages = "rt-bootstrapper.kyma-project.io/rewritten-volume-images"
+)
+
+// OriginalImages - returns the images of the rewritten containers before the
+// rewrite, keyed by the container name; returns nil if no image was rewritten
+func OriginalImages(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationOriginalImages)
+}
+
+// RecordOriginalImages - adds the original images of the containers to the
+// annotation; the images recorded already are replaced, so the annotation
+// holds the images the rewritten ones were created from even if a later
+// webhook replaced them
+func RecordOriginalImages(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationOriginalImages, images)
+}
+
+// OriginalTags - returns the tags of the images pinned to digests, keyed by
+// the container name; returns nil if no image was pinned
+func OriginalTags(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationOriginalTags)
+}
+
+// RecordOriginalTags - adds the tags of the pinned images to the annotation;
+// the tags recorded already are kept
+func RecordOriginalTags(pod *corev1.Pod, tags map[string]string) {
+	recordContainerAnnotation(pod, AnnotationOriginalTags, tags)
+}
+
+// OriginalAnnotations - returns the images of the rewritten image-bearing
+// annotations before the rewrite, keyed by the annotation key; returns nil if
+// no annotation was rewritten
+func OriginalAnnotations(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationOriginalAnnotations)
+}
+
+// RecordOriginalAnnotations - adds the original images of the image-bearing
+// annotations to the annotation; the images recorded already are replaced
+func RecordOriginalAnnotations(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationOriginalAnnotations, images)
+}
+
+// OriginalVolumeImages - returns the images of the rewritten image volumes
+// before the rewrite, keyed by the volume name; returns nil if no image
+// volume was rewritten
+func OriginalVolumeImages(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationOriginalVolumeImages)
+}
+
+// RecordOriginalVolumeImages - adds the original images of the image volumes
+// to the annotation; the images recorded already are replaced
+func RecordOriginalVolumeImages(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationOriginalVolumeImages, images)
+}
+
+// OriginalPullPolicies - returns the pull policies of the containers before
+// they were set, keyed by the container name; returns nil if no policy was
+// set
+func OriginalPullPolicies(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationOriginalPullPolicies)
+}
+
+// RecordOriginalPullPolicies - adds the original pull policies of the
+// containers to the annotation; the policies recorded already are kept
+func RecordOriginalPullPolicies(pod *corev1.Pod, policies map[string]string) {
+	recordContainerAnnotation(pod, AnnotationOriginalPullPolicies, policies)
+}
+
+// RewrittenImages - returns the images of the rewritten containers after the
+// rewrite, keyed by the container name; returns nil if no image was rewritten
+func RewrittenImages(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationRewrittenImages)
+}
+
+// RecordRewrittenImages - adds the rewritten images of the containers to the
+// annotation; the images recorded already are replaced, so the annotation
+// always holds the latest result
+func RecordRewrittenImages(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationRewrittenImages, images)
+}
+
+// RewrittenAnnotations - returns the images of the rewritten image-bearing
+// annotations after the rewrite, keyed by the annotation key; returns nil if
+// no annotation was rewritten
+func RewrittenAnnotations(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationRewrittenAnnotations)
+}
+
+// RecordRewrittenAnnotations - adds the rewritten images of the
+// image-bearing annotations to the annotation; the images recorded already
+// are replaced
+func RecordRewrittenAnnotations(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationRewrittenAnnotations, images)
+}
+
+// RewrittenVolumeImages - returns the images of the rewritten image volumes
+// after the rewrite, keyed by the volume name; returns nil if no image volume
+// was rewritten
+func RewrittenVolumeImages(pod *corev1.Pod) (map[string]string, error) {
+	return containerAnnotation(pod, AnnotationRewrittenVolumeImages)
+}
+
+// RecordRewrittenVolumeImages - adds the rewritten images of the image
+// volumes to the annotation; the images recorded already are replaced
+func RecordRewrittenVolumeImages(pod *corev1.Pod, images map[string]string) {
+	replaceContainerAnnotation(pod, AnnotationRewrittenVolumeImages, images)
+}
+
+// containerAnnotation - decodes the annotation holding a JSON map keyed by
+// the container name or, for the image-bearing annotations and the image
+// volumes, the annotation key and the volume name
+func containerAnnotation(pod *corev1.Pod, key string) (map[string]string, error) {
+	value, found := pod.Annotations[key]
+	if !found {
+		return nil, nil
+	}
+
+	var result map[string]string
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, fmt.Errorf("invalid '%s' annotation: %w", key, err)
+	}
+	return result, nil
+}
+
+// recordContainerAnnotation - merges the values into the annotation holding a
+// JSON map keyed by the container name; the values recorded already are kept
+func recordContainerAnnotation(pod *corev1.Pod, key string, values map[string]string) {
+	mergeContainerAnnotation(pod, key, values, false)
+}
+
+// replaceContainerAnnotation - like recordContainerAnnotation, but the values
+// recorded already are replaced
+func replaceContainerAnnotation(pod *corev1.Pod, key string, values map[string]string) {
+	mergeContainerAnnotation(pod, key, values, true)
+}
+
+// mergeContainerAnnotation - merges the values into the annotation holding a
+// JSON map keyed by the container name, the recorded values are replaced only
+// if replace is set
+func mergeContainerAnnotation(pod *corev1.Pod, key string, values map[string]string, replace bool) {
+	if len(values) == 0 {
+		return
+	}
+
+	// the annotation can be malformed only if it was set by someone else
+	recorded, err := containerAnnotation(pod, key)
+	if err != nil || recorded == nil {
+		recorded = map[string]string{}
+	}
+
+	var result map[string]string
+	if replace {
+		result = maps.Clone(recorded)
+		maps.Copy(result, values)
+	} else {
+		result = maps.Clone(values)
+		maps.Copy(result, recorded)
+	}
+
+	// the map of strings can always be marshalled
+	data, _ := json.Marshal(result)
+
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[key] = string(data)
+}
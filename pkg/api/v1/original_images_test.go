@@ -0,0 +1,134 @@
+package v1_test
+
+import (
+	"testing"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOriginalImages(t *testing.T) {
+	tcs := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "no image rewritten",
+			expected: nil,
+		},
+		{
+			name: "rewritten images",
+			annotations: map[string]string{
+				v1.AnnotationOriginalImages: `{"app":"nginx","init":"gcr.io/project/init:v1"}`,
+			},
+			expected: map[string]string{
+				"app":  "nginx",
+				"init": "gcr.io/project/init:v1",
+			},
+		},
+		{
+			name: "malformed annotation",
+			annotations: map[string]string{
+				v1.AnnotationOriginalImages: `nginx`,
+			},
+			expectedErr: "invalid 'rt-bootstrapper.kyma-project.io/original-images' annotation",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+
+			actual, err := v1.OriginalImages(&pod)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRecordOriginalImages(t *testing.T) {
+	var pod corev1.Pod
+
+	v1.RecordOriginalImages(&pod, nil)
+	assert.Nil(t, pod.Annotations)
+
+	v1.RecordOriginalImages(&pod, map[string]string{"app": "nginx"})
+	assert.Equal(t, `{"app":"nginx"}`, pod.Annotations[v1.AnnotationOriginalImages])
+
+	// the images replaced after the earlier rewrite replace the recorded ones
+	v1.RecordOriginalImages(&pod, map[string]string{
+		"app":     "nginx:1.27",
+		"sidecar": "gcr.io/project/sidecar:v1",
+	})
+
+	actual, err := v1.OriginalImages(&pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":     "nginx:1.27",
+		"sidecar": "gcr.io/project/sidecar:v1",
+	}, actual)
+}
+
+func TestRecordOriginalTags(t *testing.T) {
+	var pod corev1.Pod
+
+	v1.RecordOriginalTags(&pod, map[string]string{"app": "v1"})
+	v1.RecordOriginalTags(&pod, map[string]string{"app": "v2", "sidecar": "latest"})
+
+	actual, err := v1.OriginalTags(&pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "v1", "sidecar": "latest"}, actual)
+
+	// the original images are recorded separately
+	images, err := v1.OriginalImages(&pod)
+	require.NoError(t, err)
+	assert.Nil(t, images)
+}
+
+func TestRecordOriginalAnnotations(t *testing.T) {
+	var pod corev1.Pod
+
+	v1.RecordOriginalAnnotations(&pod, map[string]string{
+		"sidecar.istio.io/proxyImage": "docker.io/istio/proxyv2:1.24.0",
+	})
+
+	actual, err := v1.OriginalAnnotations(&pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"sidecar.istio.io/proxyImage": "docker.io/istio/proxyv2:1.24.0",
+	}, actual)
+	assert.NotContains(t, pod.Annotations, v1.AnnotationOriginalImages)
+}
+
+func TestRecordRewrittenImages(t *testing.T) {
+	var pod corev1.Pod
+
+	v1.RecordRewrittenImages(&pod, map[string]string{"app": "mirror.local/app:v1"})
+
+	// the latest results replace the recorded ones
+	v1.RecordRewrittenImages(&pod, map[string]string{
+		"app":     "mirror.local/app:v2",
+		"sidecar": "mirror.local/sidecar:v1",
+	})
+
+	actual, err := v1.RewrittenImages(&pod)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":     "mirror.local/app:v2",
+		"sidecar": "mirror.local/sidecar:v1",
+	}, actual)
+
+	// the original images are recorded separately
+	images, err := v1.OriginalImages(&pod)
+	require.NoError(t, err)
+	assert.Nil(t, images)
+}
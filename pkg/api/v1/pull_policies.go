@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ReferenceDigest - the images pinned to a digest
+	ReferenceDigest = "Digest"
+	// ReferenceLatest - the images with the mutable 'latest' tag or without a
+	// tag
+	ReferenceLatest = "Latest"
+	// ReferenceTag - the images with any other tag
+	ReferenceTag = "Tag"
+)
+
+var (
+	referenceKinds = []string{ReferenceDigest, ReferenceLatest, ReferenceTag}
+	pullPolicies   = []string{
+		string(corev1.PullAlways),
+		string(corev1.PullIfNotPresent),
+		string(corev1.PullNever),
+	}
+)
+
+// defaultImagePullPolicyRules - the rules applied if none are configured;
+// the mirrors are asked for the images with a mutable tag on every start
+var defaultImagePullPolicyRules = []ImagePullPolicyRule{
+	{References: []string{ReferenceLatest}, PullPolicy: corev1.PullAlways},
+	{References: []string{ReferenceDigest}, PullPolicy: corev1.PullIfNotPresent},
+}
+
+// ImagePullPolicyRule - sets the pull policy of the containers with matching
+// images, applied to the pods with the set-img-pull-policy feature enabled;
+// the images are matched after the registry rewrite
+// +kubebuilder:object:generate=true
+type ImagePullPolicyRule struct {
+	// Registries - the registries or the repository prefixes in the
+	// host[:port][/path] format the images are pulled from; all if empty
+	Registries []string `json:"registries,omitempty"`
+	// References - the kinds of the image references, 'Digest', 'Latest'
+	// (the 'latest' tag or no tag) or 'Tag' (any other tag); all if empty
+	References []string `json:"references,omitempty"`
+	// PullPolicy - the pull policy of the matching containers
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	PullPolicy corev1.PullPolicy `json:"pullPolicy" validate:"required"`
+}
+
+// referenceKind - returns the kind of the image reference
+func referenceKind(name k8s.ImageName) string {
+	if strings.Contains(name.Suffix, "@") {
+		return ReferenceDigest
+	}
+	if name.Suffix == "" || name.Suffix == ":latest" {
+		return ReferenceLatest
+	}
+	return ReferenceTag
+}
+
+func (r ImagePullPolicyRule) matches(name k8s.ImageName) bool {
+	if len(r.Registries) > 0 && !slices.ContainsFunc(r.Registries, name.HasPrefix) {
+		return false
+	}
+	return len(r.References) == 0 || slices.Contains(r.References, referenceKind(name))
+}
+
+// ImagePullPolicyRules - the pull policy rules, the first rule (in the
+// configuration order) matching the image wins
+type ImagePullPolicyRules []ImagePullPolicyRule
+
+// ImagePullPolicyRulesOrDefault - returns the pull policy rules, the default
+// ones if not configured
+func (c *Config) ImagePullPolicyRulesOrDefault() ImagePullPolicyRules {
+	if c.ImagePullPolicyRules == nil {
+		return defaultImagePullPolicyRules
+	}
+	return c.ImagePullPolicyRules
+}
+
+// PullPolicy - returns the pull policy of the image and the rule it is set
+// by, e.g. 'imagePullPolicyRules[0]'; the rule is empty if none matches
+func (r ImagePullPolicyRules) PullPolicy(name k8s.ImageName) (corev1.PullPolicy, string) {
+	index := slices.IndexFunc(r, func(rule ImagePullPolicyRule) bool {
+		return rule.matches(name)
+	})
+	if index == -1 {
+		return "", ""
+	}
+	return r[index].PullPolicy, fmt.Sprintf("imagePullPolicyRules[%d]", index)
+}
+
+func validateImagePullPolicyRules(fldPath *field.Path, rules []ImagePullPolicyRule) field.ErrorList {
+	var result field.ErrorList
+	for i, rule := range rules {
+		rulePath := fldPath.Index(i)
+
+		for j, registry := range rule.Registries {
+			result = append(result, validateRepositoryPrefix(rulePath.Child("registries").Index(j), registry)...)
+		}
+
+		for j, kind := range rule.References {
+			if !slices.Contains(referenceKinds, kind) {
+				result = append(result, field.NotSupported(rulePath.Child("references").Index(j), kind, referenceKinds))
+			}
+		}
+
+		// the missing policy is reported by the structural validation
+		if rule.PullPolicy != "" && !slices.Contains(pullPolicies, string(rule.PullPolicy)) {
+			result = append(result, field.NotSupported(rulePath.Child("pullPolicy"), rule.PullPolicy, pullPolicies))
+		}
+	}
+	return result
+}
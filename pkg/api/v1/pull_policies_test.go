@@ -0,0 +1,121 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConfig_ImagePullPolicyRulesOrDefault(t *testing.T) {
+	const digest = "@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imagePullPolicyRules:
+- registries: [mirror.local]
+  references: [Latest, Tag]
+  pullPolicy: Always
+- references: [Digest]
+  pullPolicy: IfNotPresent
+`
+
+	cfg, err := v1.NewConfig(strings.NewReader(val))
+	require.NoError(t, err)
+
+	var defaults v1.Config
+
+	tcs := []struct {
+		image        string
+		rules        v1.ImagePullPolicyRules
+		expected     corev1.PullPolicy
+		expectedRule string
+	}{
+		{
+			image:        "mirror.local/app:v1",
+			rules:        cfg.ImagePullPolicyRulesOrDefault(),
+			expected:     corev1.PullAlways,
+			expectedRule: "imagePullPolicyRules[0]",
+		},
+		{
+			image:        "mirror.local/app" + digest,
+			rules:        cfg.ImagePullPolicyRulesOrDefault(),
+			expected:     corev1.PullIfNotPresent,
+			expectedRule: "imagePullPolicyRules[1]",
+		},
+		{
+			image: "gcr.io/project/app:v1",
+			rules: cfg.ImagePullPolicyRulesOrDefault(),
+		},
+		{
+			image:        "nginx",
+			rules:        defaults.ImagePullPolicyRulesOrDefault(),
+			expected:     corev1.PullAlways,
+			expectedRule: "imagePullPolicyRules[0]",
+		},
+		{
+			image:        "gcr.io/project/app:latest",
+			rules:        defaults.ImagePullPolicyRulesOrDefault(),
+			expected:     corev1.PullAlways,
+			expectedRule: "imagePullPolicyRules[0]",
+		},
+		{
+			image:        "gcr.io/project/app:v1" + digest,
+			rules:        defaults.ImagePullPolicyRulesOrDefault(),
+			expected:     corev1.PullIfNotPresent,
+			expectedRule: "imagePullPolicyRules[1]",
+		},
+		{
+			image: "gcr.io/project/app:v1",
+			rules: defaults.ImagePullPolicyRulesOrDefault(),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.image, func(t *testing.T) {
+			name, err := k8s.ParseImage(tc.image)
+			require.NoError(t, err)
+
+			policy, rule := tc.rules.PullPolicy(name)
+			assert.Equal(t, tc.expected, policy)
+			assert.Equal(t, tc.expectedRule, rule)
+		})
+	}
+}
+
+func TestNewConfig_invalidImagePullPolicyRules(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+imagePullPolicyRules:
+- registries: [Mirror.local]
+  references: [Mutable]
+  pullPolicy: Sometimes
+- references: [Latest]
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imagePullPolicyRules[0].registries[0]: Invalid value: "Mirror.local"`,
+		`imagePullPolicyRules[0].references[0]: Unsupported value: "Mutable"`,
+		`imagePullPolicyRules[0].pullPolicy: Unsupported value: "Sometimes"`,
+		`imagePullPolicyRules[1].pullPolicy: Required value`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"slices"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ImagePullSecret - a master pull secret replicated to all namespaces and
+// injected into the pods pulling images from its registries
+// +kubebuilder:object:generate=true
+type ImagePullSecret struct {
+	Name      string `json:"name" validate:"required"`
+	Namespace string `json:"namespace" validate:"required"`
+	// Registries - the registries the secret holds credentials for; the
+	// secret defined by imagePullSecretName serves all registries
+	// +kubebuilder:validation:MinItems=1
+	Registries []string `json:"registries" validate:"required"`
+}
+
+// ServesRegistry - returns true if the secret holds credentials for the
+// registry; the registry is expected in the normalized form, e.g. 'docker.io'
+// for all the names of Docker Hub
+func (s ImagePullSecret) ServesRegistry(registry string) bool {
+	return s.Registries == nil || slices.ContainsFunc(s.Registries, func(r string) bool {
+		return k8s.NormalizeRegistry(r) == registry
+	})
+}
+
+// PullSecrets - returns all the master pull secrets, starting with the one
+// defined by imagePullSecretName (if set) which serves all registries
+func (c *Config) PullSecrets() []ImagePullSecret {
+	result := make([]ImagePullSecret, 0, len(c.ImagePullSecrets)+1)
+	if c.ImagePullSecretName != "" {
+		result = append(result, ImagePullSecret{
+			Name:      c.ImagePullSecretName,
+			Namespace: c.ImagePullSecretNamespace,
+		})
+	}
+	return append(result, c.ImagePullSecrets...)
+}
+
+func validatePullSecrets(fldPath *field.Path, legacyName string, secrets []ImagePullSecret) field.ErrorList {
+	var result field.ErrorList
+
+	// the secrets are replicated under their names, so the names have to be
+	// unique regardless of the namespace of the master secret
+	names := sets.New[string]()
+	if legacyName != "" {
+		names.Insert(legacyName)
+	}
+
+	for i, secret := range secrets {
+		secretPath := fldPath.Index(i)
+
+		if secret.Name != "" {
+			if names.Has(secret.Name) {
+				result = append(result, field.Duplicate(secretPath.Child("name"), secret.Name))
+			}
+			names.Insert(secret.Name)
+
+			result = append(result, validateDNS(secretPath.Child("name"),
+				secret.Name, validation.IsDNS1123Subdomain)...)
+		}
+
+		if secret.Namespace != "" {
+			result = append(result, validateDNS(secretPath.Child("namespace"),
+				secret.Namespace, validation.IsDNS1123Label)...)
+		}
+
+		// the missing registries are reported by the field validation
+		if secret.Registries != nil && len(secret.Registries) == 0 {
+			result = append(result, field.Required(secretPath.Child("registries"),
+				"at least one registry is required"))
+		}
+
+		for j, registry := range secret.Registries {
+			result = append(result, validateRegistry(secretPath.Child("registries").Index(j), registry)...)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,75 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagePullSecret_ServesRegistry(t *testing.T) {
+	secret := v1.ImagePullSecret{
+		Name:       "docker-hub",
+		Registries: []string{"index.docker.io", "mirror.local:5000"},
+	}
+
+	assert.True(t, secret.ServesRegistry("docker.io"))
+	assert.True(t, secret.ServesRegistry("mirror.local:5000"))
+	assert.False(t, secret.ServesRegistry("mirror.local"))
+
+	// the secret defined by imagePullSecretName serves all registries
+	assert.True(t, v1.ImagePullSecret{Name: "all"}.ServesRegistry("gcr.io"))
+}
+
+func TestNewConfig_imagePullSecrets(t *testing.T) {
+	cfg, err := v1.NewConfig(strings.NewReader(`
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+secretSyncInterval: 1m
+overrides: {}
+imagePullSecrets:
+- name: mirror-a
+  namespace: kyma-system
+  registries: [mirror-a.local]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []v1.ImagePullSecret{{
+		Name:       "mirror-a",
+		Namespace:  "kyma-system",
+		Registries: []string{"mirror-a.local"},
+	}}, cfg.PullSecrets())
+
+	_, err = v1.NewConfig(strings.NewReader(`
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: registry-credentials
+imagePullSecretNamespace: kyma-system
+secretSyncInterval: 1m
+overrides: {}
+imagePullSecrets:
+- name: registry-credentials
+  namespace: kyma-system
+  registries: [mirror-a.local]
+- name: mirror-b
+  namespace: Invalid_Namespace
+  registries: ["mirror b"]
+- name: mirror-c
+- name: mirror-d
+  namespace: kyma-system
+  registries: []
+`))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imagePullSecrets[0].name: Duplicate value: "registry-credentials"`,
+		`imagePullSecrets[1].namespace: Invalid value: "Invalid_Namespace"`,
+		`imagePullSecrets[1].registries[0]: Invalid value: "mirror b"`,
+		`imagePullSecrets[2].namespace: Required value`,
+		`imagePullSecrets[2].registries: Required value`,
+		`imagePullSecrets[3].registries: Required value: at least one registry is required`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
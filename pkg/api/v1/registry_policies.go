@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RegistryPolicy - limits the registries the pods of the matching namespaces
+// can pull images from; the images are checked after they are rewritten. The
+// namespaces are selected like by the namespace feature rules.
+// +kubebuilder:object:generate=true
+type RegistryPolicy struct {
+	// Name - identifies the policy in the rejection messages, defaults to its
+	// position in the list, e.g. 'registryPolicies[0]'
+	Name string `json:"name,omitempty"`
+	// Names - glob patterns (for example 'kyma-*'); the policy matches if any
+	// of them matches the namespace name
+	Names []string `json:"names,omitempty"`
+	// NameRegex - regular expression that has to match the whole namespace name
+	NameRegex string `json:"nameRegex,omitempty"`
+	// Selector - label selector evaluated against the namespace labels
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Allowed - registries or repository prefixes in the host[:port][/path]
+	// format the images have to be pulled from; all are allowed if empty
+	Allowed []string `json:"allowed,omitempty"`
+	// Denied - registries or repository prefixes the images must not be
+	// pulled from; takes precedence over allowed
+	Denied []string `json:"denied,omitempty"`
+}
+
+// NamespaceRegistryPolicy - the registry policy resolved for a namespace
+type NamespaceRegistryPolicy struct {
+	namespaceMatcher
+	name    string
+	allowed []string
+	denied  []string
+}
+
+// Check - returns the reason the image is not allowed by the policy, the
+// reason is empty if the image is allowed
+func (p *NamespaceRegistryPolicy) Check(image k8s.ImageName) string {
+	if p == nil {
+		return ""
+	}
+
+	if index := slices.IndexFunc(p.denied, image.HasPrefix); index != -1 {
+		return fmt.Sprintf("'%s' is denied by the registry policy '%s'", p.denied[index], p.name)
+	}
+
+	if len(p.allowed) == 0 || slices.ContainsFunc(p.allowed, image.HasPrefix) {
+		return ""
+	}
+
+	return fmt.Sprintf("registry '%s' is not allowed by the registry policy '%s', allowed: %s",
+		image.Registry, p.name, strings.Join(p.allowed, ", "))
+}
+
+// RegistryPolicyMatcher - resolves the registry policy of a namespace; the
+// first policy (in the configuration order) matching the namespace wins
+type RegistryPolicyMatcher struct {
+	policies []NamespaceRegistryPolicy
+}
+
+// RegistryPolicyMatcher - builds the matcher of the namespace registry policies
+func (c *Config) RegistryPolicyMatcher() (*RegistryPolicyMatcher, error) {
+	result := RegistryPolicyMatcher{
+		policies: make([]NamespaceRegistryPolicy, 0, len(c.RegistryPolicies)),
+	}
+
+	for i, policy := range c.RegistryPolicies {
+		name := policy.Name
+		if name == "" {
+			name = fmt.Sprintf("registryPolicies[%d]", i)
+		}
+
+		matcher, err := compileNamespaceMatcher(policy.Names, policy.NameRegex, policy.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("registry policy '%s': %w", name, err)
+		}
+
+		result.policies = append(result.policies, NamespaceRegistryPolicy{
+			namespaceMatcher: matcher,
+			name:             name,
+			allowed:          policy.Allowed,
+			denied:           policy.Denied,
+		})
+	}
+
+	return &result, nil
+}
+
+// Empty - returns true if there are no registry policies
+func (m *RegistryPolicyMatcher) Empty() bool {
+	return m == nil || len(m.policies) == 0
+}
+
+// Policy - returns the registry policy of the namespace, nil if none matches
+func (m *RegistryPolicyMatcher) Policy(ns *corev1.Namespace) *NamespaceRegistryPolicy {
+	if m == nil || ns == nil {
+		return nil
+	}
+
+	for i := range m.policies {
+		if m.policies[i].matches(ns) {
+			return &m.policies[i]
+		}
+	}
+	return nil
+}
+
+func validateRegistryPolicies(fldPath *field.Path, policies []RegistryPolicy) field.ErrorList {
+	var result field.ErrorList
+
+	names := sets.New[string]()
+	for i, policy := range policies {
+		policyPath := fldPath.Index(i)
+
+		if policy.Name != "" {
+			if names.Has(policy.Name) {
+				result = append(result, field.Duplicate(policyPath.Child("name"), policy.Name))
+			}
+			names.Insert(policy.Name)
+		}
+
+		result = append(result, validateNamespaceMatcher(policyPath,
+			policy.Names, policy.NameRegex, policy.Selector)...)
+
+		if len(policy.Allowed) == 0 && len(policy.Denied) == 0 {
+			result = append(result, field.Required(policyPath,
+				"at least one of allowed or denied is required"))
+		}
+
+		for j, prefix := range policy.Allowed {
+			result = append(result, validateRepositoryPrefix(policyPath.Child("allowed").Index(j), prefix)...)
+		}
+
+		for j, prefix := range policy.Denied {
+			result = append(result, validateRepositoryPrefix(policyPath.Child("denied").Index(j), prefix)...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegistryPolicyMatcher(t *testing.T) {
+	cfg := v1.Config{
+		RegistryPolicies: []v1.RegistryPolicy{
+			{
+				Name:    "mirror-only",
+				Names:   []string{"ns2-*"},
+				Allowed: []string{"mirror.local"},
+				Denied:  []string{"mirror.local/untrusted"},
+			},
+			{
+				NameRegex: "team-[0-9]+",
+				Denied:    []string{"index.docker.io"},
+			},
+		},
+	}
+
+	matcher, err := cfg.RegistryPolicyMatcher()
+	require.NoError(t, err)
+	assert.False(t, matcher.Empty())
+
+	newNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	tcs := []struct {
+		namespace string
+		image     string
+		expected  string
+	}{
+		{namespace: "ns2-a", image: "mirror.local/app:v1"},
+		{
+			namespace: "ns2-a",
+			image:     "mirror.local/untrusted/app:v1",
+			expected:  "'mirror.local/untrusted' is denied by the registry policy 'mirror-only'",
+		},
+		{
+			namespace: "ns2-a",
+			image:     "gcr.io/app:v1",
+			expected:  "registry 'gcr.io' is not allowed by the registry policy 'mirror-only', allowed: mirror.local",
+		},
+		{namespace: "team-1", image: "gcr.io/app:v1"},
+		{
+			namespace: "team-1",
+			image:     "nginx",
+			expected:  "'index.docker.io' is denied by the registry policy 'registryPolicies[1]'",
+		},
+		{namespace: "other", image: "nginx"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.namespace+"/"+tc.image, func(t *testing.T) {
+			image, err := k8s.ParseImage(tc.image)
+			require.NoError(t, err)
+
+			policy := matcher.Policy(newNamespace(tc.namespace))
+			assert.Equal(t, tc.expected, policy.Check(image))
+		})
+	}
+}
+
+func TestNewConfig_invalidRegistryPolicies(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+registryPolicies:
+- name: restricted
+  names: ["ns2-*"]
+  allowed: ["Mirror.local"]
+- name: restricted
+  nameRegex: "team-("
+  denied: ["docker.io/"]
+- allowed: []
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`registryPolicies[0].allowed[0]: Invalid value: "Mirror.local"`,
+		`registryPolicies[1].name: Duplicate value: "restricted"`,
+		`registryPolicies[1].nameRegex: Invalid value: "team-("`,
+		`registryPolicies[1].denied[0]: Invalid value: "docker.io/"`,
+		`registryPolicies[2]: Required value: at least one of names, nameRegex or selector is required`,
+		`registryPolicies[2]: Required value: at least one of allowed or denied is required`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"path"
+	"slices"
+
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// reservedEnvVars - env variables managed by the pod defaulters, the
+// namespace bootstrap policies can not set them
+var reservedEnvVars = []string{
+	EnvKymaFipsModeEnabled,
+}
+
+// TenantPolicy - the limits of the settings namespace owners can choose with
+// the NamespaceBootstrapPolicy resource; the policies are ignored if the
+// configuration does not define the limits
+// +kubebuilder:object:generate=true
+type TenantPolicy struct {
+	// AllowedFeatures - features the policies can enable or disable
+	AllowedFeatures []string `json:"allowedFeatures,omitempty"`
+	// ClusterTrustBundles - trust bundles the policies can select by name
+	ClusterTrustBundles []k8s.ClusterTrustBundle `json:"clusterTrustBundles,omitempty" validate:"dive"`
+	// AllowedEnvVars - glob patterns of the env variable names the policies
+	// can add to the containers
+	AllowedEnvVars []string `json:"allowedEnvVars,omitempty"`
+}
+
+// AllowsFeature - returns true if the policies can enable or disable the feature
+func (p *TenantPolicy) AllowsFeature(feature string) bool {
+	return p != nil && slices.Contains(p.AllowedFeatures, feature)
+}
+
+// ClusterTrustBundle - returns the trust bundle the policies can select
+// with the given name
+func (p *TenantPolicy) ClusterTrustBundle(name string) (k8s.ClusterTrustBundle, bool) {
+	if p == nil {
+		return k8s.ClusterTrustBundle{}, false
+	}
+
+	index := slices.IndexFunc(p.ClusterTrustBundles, func(ctb k8s.ClusterTrustBundle) bool {
+		return ctb.Name == name
+	})
+	if index == -1 {
+		return k8s.ClusterTrustBundle{}, false
+	}
+	return p.ClusterTrustBundles[index], true
+}
+
+// AllowsEnvVar - returns true if the policies can add the env variable
+func (p *TenantPolicy) AllowsEnvVar(name string) bool {
+	if p == nil || slices.Contains(reservedEnvVars, name) {
+		return false
+	}
+
+	return slices.ContainsFunc(p.AllowedEnvVars, func(pattern string) bool {
+		// patterns are validated with the configuration
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
+
+func (p *TenantPolicy) validate(fldPath *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	for i, feature := range p.AllowedFeatures {
+		result = append(result, validateFeature(fldPath.Child("allowedFeatures").Index(i), feature)...)
+	}
+
+	names := sets.New[string]()
+	for i, ctb := range p.ClusterTrustBundles {
+		ctbPath := fldPath.Child("clusterTrustBundles").Index(i)
+		if names.Has(ctb.Name) {
+			result = append(result, field.Duplicate(ctbPath.Child("name"), ctb.Name))
+		}
+		names.Insert(ctb.Name)
+
+		if ctb.VolumeName != "" {
+			result = append(result, validateDNS(ctbPath.Child("volumeName"),
+				ctb.VolumeName, validation.IsDNS1123Label)...)
+		}
+	}
+
+	for i, pattern := range p.AllowedEnvVars {
+		if _, err := path.Match(pattern, ""); err != nil {
+			result = append(result, field.Invalid(fldPath.Child("allowedEnvVars").Index(i), pattern, err.Error()))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package v1_test
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/kyma-project/rt-bootstrapper/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig_tenantPolicy(t *testing.T) {
+	val := `
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 1m
+overrides: {}
+tenantPolicy:
+  allowedFeatures: [rt-cfg.kyma-project.io/unknown]
+  clusterTrustBundles:
+  - name: ctb
+    certWritePath: ca.pem
+    volumeMountPath: /etc/ssl/certs
+    volumeName: Invalid_Volume
+  - name: ctb
+    certWritePath: ca.pem
+    volumeMountPath: /etc/ssl/certs
+  allowedEnvVars: ["TENANT_["]
+`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`tenantPolicy.allowedFeatures[0]: Unsupported value: "rt-cfg.kyma-project.io/unknown"`,
+		`tenantPolicy.clusterTrustBundles[0].volumeName: Invalid value: "Invalid_Volume"`,
+		`tenantPolicy.clusterTrustBundles[1].name: Duplicate value: "ctb"`,
+		`tenantPolicy.clusterTrustBundles[1].volumeName: Required value`,
+		`tenantPolicy.allowedEnvVars[0]: Invalid value: "TENANT_["`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestTenantPolicy_AllowsEnvVar(t *testing.T) {
+	policy := &v1.TenantPolicy{AllowedEnvVars: []string{"TENANT_*", v1.EnvKymaFipsModeEnabled}}
+
+	assert.True(t, policy.AllowsEnvVar("TENANT_ID"))
+	assert.False(t, policy.AllowsEnvVar("OTHER"))
+	// reserved variables can not be allowed
+	assert.False(t, policy.AllowsEnvVar(v1.EnvKymaFipsModeEnabled))
+
+	var disabled *v1.TenantPolicy
+	assert.False(t, disabled.AllowsEnvVar("TENANT_ID"))
+}
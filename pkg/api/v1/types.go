@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"slices"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
 )
 
@@ -15,6 +15,8 @@ const (
 	AnnotationSetPullSecret         = "rt-cfg.kyma-project.io/add-img-pull-secret"
 	AnnotationAddClusterTrustBundle = "rt-cfg.kyma-project.io/add-cluster-trust-bundle"
 	AnnotationSetFipsMode           = "rt-cfg.kyma-project.io/set-fips-mode"
+	AnnotationPinImgDigest          = "rt-cfg.kyma-project.io/pin-img-digest"
+	AnnotationSetImgPullPolicy      = "rt-cfg.kyma-project.io/set-img-pull-policy"
 	AnnotationDefaulted             = "rt-bootstrapper.kyma-project.io/defaulted"
 	FiledManager                    = "rt-bootstrapper"
 	EnvKymaFipsModeEnabled          = "KYMA_FIPS_MODE_ENABLED"
@@ -22,6 +24,30 @@ const (
 
 type NamespaceFeatures map[string][]string
 
+// DeepCopyInto - copies the receiver into out; written by hand because the
+// generated deepcopy of the pointer to the named map does not compile
+func (in *NamespaceFeatures) DeepCopyInto(out *NamespaceFeatures) {
+	if *in == nil {
+		*out = nil
+		return
+	}
+
+	*out = make(NamespaceFeatures, len(*in))
+	for key, val := range *in {
+		(*out)[key] = slices.Clone(val)
+	}
+}
+
+// DeepCopy - returns a deep copy of the receiver
+func (in *NamespaceFeatures) DeepCopy() *NamespaceFeatures {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceFeatures)
+	in.DeepCopyInto(out)
+	return out
+}
+
 func (f NamespaceFeatures) Features(nsName string) map[string]string {
 	featureList, found := f[nsName]
 	if !found {
@@ -35,15 +61,59 @@ func (f NamespaceFeatures) Features(nsName string) map[string]string {
 	return result
 }
 
+// +kubebuilder:object:generate=true
 type Config struct {
 	Overrides                 map[string]string       `json:"overrides" validate:"required"`
-	ImagePullSecretName       string                  `json:"imagePullSecretName" validate:"required"`
-	ImagePullSecretNamespace  string                  `json:"imagePullSecretNamespace" validate:"required"`
+	ImagePullSecretName       string                  `json:"imagePullSecretName,omitempty" validate:"required_without=ImagePullSecrets"`
+	ImagePullSecretNamespace  string                  `json:"imagePullSecretNamespace,omitempty" validate:"required_without=ImagePullSecrets,required_with=ImagePullSecretName"`
 	SecretSyncInterval        Duration                `json:"secretSyncInterval" validate:"required"`
 	ClusterTrustBundleMapping *k8s.ClusterTrustBundle `json:"clusterTrustBundle,omitempty"`
 	NamespaceFeatures         *NamespaceFeatures      `json:"namespaceFeatures,omitempty"`
+	NamespaceFeatureRules     []NamespaceFeatureRule  `json:"namespaceFeatureRules,omitempty"`
+	// DefaultFeatures - features enabled for all namespaces, in addition to
+	// the ones of namespaceFeatures or namespaceFeatureRules
+	DefaultFeatures []string `json:"defaultFeatures,omitempty"`
+	// ImagePullSecrets - master pull secrets injected only into the pods
+	// pulling images from their registries
+	ImagePullSecrets []ImagePullSecret `json:"imagePullSecrets,omitempty" validate:"dive"`
+	// TenantPolicy - limits of the settings the namespace bootstrap policies
+	// can choose
+	TenantPolicy *TenantPolicy `json:"tenantPolicy,omitempty"`
+	// ImageRewriteRules - rewrite rules applied to the images not matched by
+	// the overrides, in the configuration order
+	ImageRewriteRules []ImageRewriteRule `json:"imageRewriteRules,omitempty" validate:"dive"`
+	// ImageRewriteExclusions - the images the overrides and the rewrite
+	// rules are never applied to, in all or in the given namespaces
+	ImageRewriteExclusions *ImageRewriteExclusions `json:"imageRewriteExclusions,omitempty"`
+	// InvalidImagePolicy - handling of the malformed image references, either
+	// 'Reject' (default) or 'Skip'
+	// +kubebuilder:validation:Enum=Reject;Skip
+	InvalidImagePolicy string `json:"invalidImagePolicy,omitempty"`
+	// ImageAnnotations - the keys of the pod annotations holding images read
+	// by the webhooks running later, e.g. 'sidecar.istio.io/proxyImage'; the
+	// images are rewritten like the container images
+	ImageAnnotations []string `json:"imageAnnotations,omitempty"`
+	// RegistryPolicies - limits of the registries the pods can pull images
+	// from, per namespace
+	RegistryPolicies []RegistryPolicy `json:"registryPolicies,omitempty"`
+	// MirrorCheck - verifies the rewritten images exist in the mirror,
+	// disabled if not set
+	MirrorCheck *MirrorCheck `json:"mirrorCheck,omitempty"`
+	// DigestPinning - the resolution of the image tags to digests for the
+	// pods with the pin-img-digest feature, the defaults apply if not set
+	DigestPinning *DigestPinning `json:"digestPinning,omitempty"`
+	// MirrorFailover - the fallback mirrors of the overrides and the health
+	// probes of the mirrors, disabled if not set
+	MirrorFailover *MirrorFailover `json:"mirrorFailover,omitempty"`
+	// ImagePullPolicyRules - the pull policies of the containers of the pods
+	// with the set-img-pull-policy feature, the default rules apply if not
+	// set
+	ImagePullPolicyRules []ImagePullPolicyRule `json:"imagePullPolicyRules,omitempty" validate:"dive"`
 }
 
+// Duration - a duration in the time.ParseDuration format; plain numbers are
+// read as nanoseconds
+// +kubebuilder:validation:Type=string
 type Duration time.Duration
 
 func (d Duration) MarshalJSON() ([]byte, error) {
@@ -72,15 +142,18 @@ func (d *Duration) UnmarshalJSON(p []byte) error {
 	}
 }
 
+// NewConfig - decodes the versioned configuration document, converts it into
+// the internal configuration and validates it
 func NewConfig(r io.Reader) (*Config, error) {
-	var out Config
-	err := json.NewDecoder(r).Decode(&out)
-
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	return &out, validate.Struct(out)
+	out, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
 
+	return out, out.Validate()
 }
@@ -1,6 +1,7 @@
 package v1_test
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 	"time"
@@ -33,6 +34,72 @@ func TestNewConfig(t *testing.T) {
 				SecretSyncInterval:       v1.Duration(10 * time.Minute),
 			},
 		},
+		{
+			name: "versioned v1 document",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v1",
+  "kind": "Config",
+  "imagePullSecretName": "ipsn3",
+  "imagePullSecretNamespace": "ipsns3",
+  "secretSyncInterval": "5m",
+  "overrides": { "rn3": "orn3" }
+}`,
+			expected: v1.Config{
+				Overrides: map[string]string{
+					"rn3": "orn3",
+				},
+				ImagePullSecretName:      "ipsn3",
+				ImagePullSecretNamespace: "ipsns3",
+				SecretSyncInterval:       v1.Duration(5 * time.Minute),
+			},
+		},
+		{
+			name: "versioned v1alpha1 document",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v1alpha1",
+  "kind": "Config",
+  "imagePullSecretName": "ipsn4",
+  "imagePullSecretNamespace": "ipsns4",
+  "secretSyncInterval": "1h",
+  "overrides": { "rn4": "orn4" }
+}`,
+			expected: v1.Config{
+				Overrides: map[string]string{
+					"rn4": "orn4",
+				},
+				ImagePullSecretName:      "ipsn4",
+				ImagePullSecretNamespace: "ipsns4",
+				SecretSyncInterval:       v1.Duration(time.Hour),
+			},
+		},
+		{
+			name: "YAML document",
+			val: `# hand-edited configuration
+apiVersion: rt-bootstrapper.kyma-project.io/v1
+kind: Config
+imagePullSecretName: ipsn5
+imagePullSecretNamespace: ipsns5
+secretSyncInterval: 90s # comments are allowed
+overrides:
+  rn5: orn5
+  gcr.io/project-a: mirror.local/a
+namespaceFeatures:
+  kyma-system:
+  - rt-cfg.kyma-project.io/alter-img-registry
+`,
+			expected: v1.Config{
+				Overrides: map[string]string{
+					"rn5":              "orn5",
+					"gcr.io/project-a": "mirror.local/a",
+				},
+				ImagePullSecretName:      "ipsn5",
+				ImagePullSecretNamespace: "ipsns5",
+				SecretSyncInterval:       v1.Duration(90 * time.Second),
+				NamespaceFeatures: &v1.NamespaceFeatures{
+					"kyma-system": {"rt-cfg.kyma-project.io/alter-img-registry"},
+				},
+			},
+		},
 		{
 			name: "default secret-sync-interval",
 			val: `{ 
@@ -61,3 +128,180 @@ func TestNewConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewConfig_invalidDocument(t *testing.T) {
+	tcs := []struct {
+		name        string
+		val         string
+		expectedErr string
+	}{
+		{
+			name: "unknown apiVersion",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v2",
+  "kind": "Config"
+}`,
+			expectedErr: "unsupported apiVersion 'rt-bootstrapper.kyma-project.io/v2'",
+		},
+		{
+			name: "unknown kind",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v1",
+  "kind": "Pod"
+}`,
+			expectedErr: "unsupported kind 'Pod'",
+		},
+		{
+			name: "missing kind",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v1"
+}`,
+			expectedErr: "unsupported kind ''",
+		},
+		{
+			name: "unknown field in v1 document",
+			val: `{
+  "apiVersion": "rt-bootstrapper.kyma-project.io/v1",
+  "kind": "Config",
+  "imagePullSecretName": "ipsn",
+  "imagePullSecretNamespace": "ipsns",
+  "secretSyncInterval": "1m",
+  "overrides": {},
+  "overides": { "typo": "here" }
+}`,
+			expectedErr: `unknown field "overides"`,
+		},
+		{
+			name: "unknown field in unversioned document",
+			val: `{
+  "imagePullSecretName": "ipsn",
+  "imagePullSecretNamespace": "ipsns",
+  "secretSyncInterval": "1m",
+  "overrides": {},
+  "imagePullSecret": "typo"
+}`,
+			expectedErr: `unknown field "imagePullSecret"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v1.NewConfig(strings.NewReader(tc.val))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func TestMarshalConfig(t *testing.T) {
+	expected := v1.Config{
+		Overrides: map[string]string{
+			"rn": "orn",
+		},
+		ImagePullSecretName:      "ipsn",
+		ImagePullSecretNamespace: "ipsns",
+		SecretSyncInterval:       v1.Duration(time.Minute),
+	}
+
+	data, err := v1.MarshalConfig(expected)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"apiVersion":"rt-bootstrapper.kyma-project.io/v1"`)
+
+	actual, err := v1.NewConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, expected, *actual)
+}
+
+func TestNewConfig_semanticValidation(t *testing.T) {
+	val := `{
+  "imagePullSecretName": "Invalid_Secret",
+  "imagePullSecretNamespace": "kyma.system",
+  "secretSyncInterval": "-1m",
+  "overrides": {
+    "docker io": "mirror.local",
+    "gcr.io": "mirror.local:99999",
+    "quay.io:abc": "mirror.local",
+    "ghcr.io/Org": "mirror.local/org",
+    "ghcr.io/team/": "mirror.local/team"
+  },
+  "namespaceFeatures": {
+    "kyma-system": [
+      "rt-cfg.kyma-project.io/add-img-pull-secret",
+      "rt-cfg.kyma-project.io/add-img-pull-secrets"
+    ],
+    "Invalid_Namespace": []
+  }
+}`
+
+	_, err := v1.NewConfig(strings.NewReader(val))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		`imagePullSecretName: Invalid value: "Invalid_Secret"`,
+		`imagePullSecretNamespace: Invalid value: "kyma.system"`,
+		`secretSyncInterval: Invalid value: "-1m0s"`,
+		`overrides[docker io]: Invalid value: "docker io"`,
+		`overrides[gcr.io]: Invalid value: "mirror.local:99999"`,
+		`overrides[quay.io:abc]: Invalid value: "quay.io:abc"`,
+		`overrides[ghcr.io/Org]: Invalid value: "ghcr.io/Org"`,
+		`overrides[ghcr.io/team/]: Invalid value: "ghcr.io/team/"`,
+		`namespaceFeatures[kyma-system][1]: Unsupported value: "rt-cfg.kyma-project.io/add-img-pull-secrets"`,
+		`namespaceFeatures[Invalid_Namespace]: Invalid value: "Invalid_Namespace"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestNewConfig_requiredFields(t *testing.T) {
+	_, err := v1.NewConfig(strings.NewReader(`{
+  "clusterTrustBundle": { "name": "ctb" }
+}`))
+	require.Error(t, err)
+
+	for _, expected := range []string{
+		"overrides: Required value",
+		"imagePullSecretName: Required value",
+		"imagePullSecretNamespace: Required value",
+		"secretSyncInterval: Required value",
+		"clusterTrustBundle.certWritePath: Required value",
+		"clusterTrustBundle.volumeName: Required value",
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestNewConfig_yamlEqualsJSON(t *testing.T) {
+	jsonDoc := `{
+  "imagePullSecretName": "ipsn",
+  "imagePullSecretNamespace": "ipsns",
+  "secretSyncInterval": 60000000000,
+  "overrides": { "rn": "orn" },
+  "namespaceFeatures": { "test": ["rt-cfg.kyma-project.io/set-fips-mode"] }
+}`
+	yamlDoc := `
+imagePullSecretName: ipsn
+imagePullSecretNamespace: ipsns
+secretSyncInterval: 60000000000
+overrides: { rn: orn }
+namespaceFeatures:
+  test: [rt-cfg.kyma-project.io/set-fips-mode]
+`
+
+	fromJSON, err := v1.NewConfig(strings.NewReader(jsonDoc))
+	require.NoError(t, err)
+
+	fromYAML, err := v1.NewConfig(strings.NewReader(yamlDoc))
+	require.NoError(t, err)
+
+	assert.Equal(t, fromJSON, fromYAML)
+	assert.Equal(t, v1.Duration(time.Minute), fromYAML.SecretSyncInterval)
+}
+
+func TestNewConfig_invalidYAML(t *testing.T) {
+	_, err := v1.NewConfig(strings.NewReader(`
+imagePullSecretName: ipsn
+imagePullSecretName: duplicated
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to decode YAML configuration")
+}
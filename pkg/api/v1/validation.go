@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// KnownFeatures - the feature annotations understood by the pod defaulters
+var KnownFeatures = []string{
+	AnnotationAlterImgRegistry,
+	AnnotationSetPullSecret,
+	AnnotationAddClusterTrustBundle,
+	AnnotationSetFipsMode,
+	AnnotationPinImgDigest,
+	AnnotationSetImgPullPolicy,
+}
+
+// Validate - runs the structural and the semantic validation of the
+// configuration and reports all the problems at once
+func (c *Config) Validate() error {
+	errs := validateStruct(c)
+	errs = append(errs, c.validateSemantics()...)
+	return errs.ToAggregate()
+}
+
+// validateStruct - runs the validation defined by the 'validate' tags and
+// reports the problems with their JSON paths
+func validateStruct(c *Config) field.ErrorList {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := validate.Struct(c)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return field.ErrorList{field.InternalError(nil, err)}
+	}
+
+	var result field.ErrorList
+	for _, fieldErr := range validationErrs {
+		// strip the name of the root struct
+		_, path, _ := strings.Cut(fieldErr.Namespace(), ".")
+		fldPath := field.NewPath(path)
+
+		// conditionally required fields are reported as required as well
+		if strings.HasPrefix(fieldErr.Tag(), "required") {
+			result = append(result, field.Required(fldPath, ""))
+			continue
+		}
+		result = append(result, field.Invalid(fldPath, fieldErr.Value(), fieldErr.Error()))
+	}
+	return result
+}
+
+func (c *Config) validateSemantics() field.ErrorList {
+	var result field.ErrorList
+
+	overridesPath := field.NewPath("overrides")
+	for _, prefix := range sortedKeys(c.Overrides) {
+		result = append(result, validateRepositoryPrefix(overridesPath.Key(prefix), prefix)...)
+		result = append(result, validateRepositoryPrefix(overridesPath.Key(prefix), c.Overrides[prefix])...)
+	}
+
+	result = append(result, validateImageRewriteRules(field.NewPath("imageRewriteRules"), c.ImageRewriteRules)...)
+	if c.ImageRewriteExclusions != nil {
+		result = append(result, c.ImageRewriteExclusions.validate(field.NewPath("imageRewriteExclusions"))...)
+	}
+
+	result = append(result, validateInvalidImagePolicy(field.NewPath("invalidImagePolicy"), c.InvalidImagePolicy)...)
+	result = append(result, validateImageAnnotations(field.NewPath("imageAnnotations"), c.ImageAnnotations)...)
+	result = append(result, validateImagePullPolicyRules(field.NewPath("imagePullPolicyRules"), c.ImagePullPolicyRules)...)
+	result = append(result, validateRegistryPolicies(field.NewPath("registryPolicies"), c.RegistryPolicies)...)
+
+	if c.ImagePullSecretName != "" {
+		result = append(result, validateDNS(field.NewPath("imagePullSecretName"),
+			c.ImagePullSecretName, validation.IsDNS1123Subdomain)...)
+	}
+
+	if c.ImagePullSecretNamespace != "" {
+		result = append(result, validateDNS(field.NewPath("imagePullSecretNamespace"),
+			c.ImagePullSecretNamespace, validation.IsDNS1123Label)...)
+	}
+
+	result = append(result, validatePullSecrets(field.NewPath("imagePullSecrets"),
+		c.ImagePullSecretName, c.ImagePullSecrets)...)
+
+	// the missing interval is reported by the structural validation
+	if c.SecretSyncInterval < 0 {
+		result = append(result, field.Invalid(field.NewPath("secretSyncInterval"),
+			time.Duration(c.SecretSyncInterval).String(), "must be greater than zero"))
+	}
+
+	if c.ClusterTrustBundleMapping != nil && c.ClusterTrustBundleMapping.VolumeName != "" {
+		result = append(result, validateDNS(field.NewPath("clusterTrustBundle", "volumeName"),
+			c.ClusterTrustBundleMapping.VolumeName, validation.IsDNS1123Label)...)
+	}
+
+	if c.NamespaceFeatures != nil {
+		result = append(result, c.NamespaceFeatures.validate(field.NewPath("namespaceFeatures"), c.ImageRewriteExclusions)...)
+	}
+
+	for i, feature := range c.DefaultFeatures {
+		result = append(result, validateNamespaceFeature(field.NewPath("defaultFeatures").Index(i),
+			feature, c.ImageRewriteExclusions)...)
+	}
+
+	rulesPath := field.NewPath("namespaceFeatureRules")
+	for i, rule := range c.NamespaceFeatureRules {
+		result = append(result, rule.validate(rulesPath.Index(i), c.ImageRewriteExclusions)...)
+	}
+
+	if c.MirrorCheck != nil {
+		result = append(result, c.MirrorCheck.validate(field.NewPath("mirrorCheck"))...)
+	}
+
+	if c.DigestPinning != nil {
+		result = append(result, c.DigestPinning.validate(field.NewPath("digestPinning"))...)
+	}
+
+	if c.MirrorFailover != nil {
+		result = append(result, c.MirrorFailover.validate(field.NewPath("mirrorFailover"), c.Overrides)...)
+	}
+
+	if c.TenantPolicy != nil {
+		result = append(result, c.TenantPolicy.validate(field.NewPath("tenantPolicy"))...)
+	}
+
+	return result
+}
+
+func (f NamespaceFeatures) validate(fldPath *field.Path, exclusions *ImageRewriteExclusions) field.ErrorList {
+	var result field.ErrorList
+	for _, nsName := range sortedKeys(f) {
+		nsPath := fldPath.Key(nsName)
+		result = append(result, validateDNS(nsPath, nsName, validation.IsDNS1123Label)...)
+
+		for i, feature := range f[nsName] {
+			result = append(result, validateNamespaceFeature(nsPath.Index(i), feature, exclusions)...)
+		}
+	}
+	return result
+}
+
+func validateFeature(fldPath *field.Path, feature string) field.ErrorList {
+	if slices.Contains(KnownFeatures, feature) {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(fldPath, feature, KnownFeatures)}
+}
+
+func validateDNS(fldPath *field.Path, value string, isValid func(string) []string) field.ErrorList {
+	var result field.ErrorList
+	for _, msg := range isValid(value) {
+		result = append(result, field.Invalid(fldPath, value, msg))
+	}
+	return result
+}
+
+// validateRegistry - checks if the value is a valid registry in the
+// host[:port] format
+func validateRegistry(fldPath *field.Path, registry string) field.ErrorList {
+	host, port, hasPort := strings.Cut(registry, ":")
+
+	var result field.ErrorList
+	if hasPort {
+		portNum, err := strconv.Atoi(port)
+		if err != nil {
+			result = append(result, field.Invalid(fldPath, registry,
+				fmt.Sprintf("invalid port '%s'", port)))
+		} else {
+			for _, msg := range validation.IsValidPortNum(portNum) {
+				result = append(result, field.Invalid(fldPath, registry, msg))
+			}
+		}
+	}
+
+	if net.ParseIP(host) != nil {
+		return result
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(host) {
+		result = append(result, field.Invalid(fldPath, registry,
+			"must be a valid registry in the host[:port] format: "+msg))
+	}
+	return result
+}
+
+// repositoryPathComponent - a component of the repository path as defined by
+// the OCI distribution specification
+var repositoryPathComponent = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+
+// validateRepositoryPrefix - checks if the value is a valid repository prefix
+// in the host[:port][/path] format
+func validateRepositoryPrefix(fldPath *field.Path, prefix string) field.ErrorList {
+	registry, path, hasPath := strings.Cut(prefix, "/")
+
+	result := validateRegistry(fldPath, registry)
+	if !hasPath {
+		return result
+	}
+
+	for _, component := range strings.Split(path, "/") {
+		if repositoryPathComponent.MatchString(component) {
+			continue
+		}
+		result = append(result, field.Invalid(fldPath, prefix,
+			fmt.Sprintf("invalid repository path component '%s'", component)))
+	}
+	return result
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,406 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"github.com/kyma-project/rt-bootstrapper/internal/webhook/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Config) DeepCopyInto(out *Config) {
+	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClusterTrustBundleMapping != nil {
+		in, out := &in.ClusterTrustBundleMapping, &out.ClusterTrustBundleMapping
+		*out = new(k8s.ClusterTrustBundle)
+		**out = **in
+	}
+	if in.NamespaceFeatures != nil {
+		in, out := &in.NamespaceFeatures, &out.NamespaceFeatures
+		*out = (*in).DeepCopy()
+	}
+	if in.NamespaceFeatureRules != nil {
+		in, out := &in.NamespaceFeatureRules, &out.NamespaceFeatureRules
+		*out = make([]NamespaceFeatureRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultFeatures != nil {
+		in, out := &in.DefaultFeatures, &out.DefaultFeatures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]ImagePullSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TenantPolicy != nil {
+		in, out := &in.TenantPolicy, &out.TenantPolicy
+		*out = new(TenantPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageRewriteRules != nil {
+		in, out := &in.ImageRewriteRules, &out.ImageRewriteRules
+		*out = make([]ImageRewriteRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageRewriteExclusions != nil {
+		in, out := &in.ImageRewriteExclusions, &out.ImageRewriteExclusions
+		*out = new(ImageRewriteExclusions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageAnnotations != nil {
+		in, out := &in.ImageAnnotations, &out.ImageAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegistryPolicies != nil {
+		in, out := &in.RegistryPolicies, &out.RegistryPolicies
+		*out = make([]RegistryPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MirrorCheck != nil {
+		in, out := &in.MirrorCheck, &out.MirrorCheck
+		*out = new(MirrorCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DigestPinning != nil {
+		in, out := &in.DigestPinning, &out.DigestPinning
+		*out = new(DigestPinning)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MirrorFailover != nil {
+		in, out := &in.MirrorFailover, &out.MirrorFailover
+		*out = new(MirrorFailover)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullPolicyRules != nil {
+		in, out := &in.ImagePullPolicyRules, &out.ImagePullPolicyRules
+		*out = make([]ImagePullPolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Config.
+func (in *Config) DeepCopy() *Config {
+	if in == nil {
+		return nil
+	}
+	out := new(Config)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DigestPinning) DeepCopyInto(out *DigestPinning) {
+	*out = *in
+	in.RegistryQuery.DeepCopyInto(&out.RegistryQuery)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DigestPinning.
+func (in *DigestPinning) DeepCopy() *DigestPinning {
+	if in == nil {
+		return nil
+	}
+	out := new(DigestPinning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageExclusions) DeepCopyInto(out *ImageExclusions) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageExclusions.
+func (in *ImageExclusions) DeepCopy() *ImageExclusions {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageExclusions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePullPolicyRule) DeepCopyInto(out *ImagePullPolicyRule) {
+	*out = *in
+	if in.Registries != nil {
+		in, out := &in.Registries, &out.Registries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePullPolicyRule.
+func (in *ImagePullPolicyRule) DeepCopy() *ImagePullPolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePullPolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePullSecret) DeepCopyInto(out *ImagePullSecret) {
+	*out = *in
+	if in.Registries != nil {
+		in, out := &in.Registries, &out.Registries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePullSecret.
+func (in *ImagePullSecret) DeepCopy() *ImagePullSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePullSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageRewriteExclusions) DeepCopyInto(out *ImageRewriteExclusions) {
+	*out = *in
+	in.ImageExclusions.DeepCopyInto(&out.ImageExclusions)
+	if in.Named != nil {
+		in, out := &in.Named, &out.Named
+		*out = make(map[string]ImageExclusions, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRewriteExclusions.
+func (in *ImageRewriteExclusions) DeepCopy() *ImageRewriteExclusions {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageRewriteExclusions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorCheck) DeepCopyInto(out *MirrorCheck) {
+	*out = *in
+	in.RegistryQuery.DeepCopyInto(&out.RegistryQuery)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorCheck.
+func (in *MirrorCheck) DeepCopy() *MirrorCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorFailover) DeepCopyInto(out *MirrorFailover) {
+	*out = *in
+	if in.Fallbacks != nil {
+		in, out := &in.Fallbacks, &out.Fallbacks
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.PlainHTTPRegistries != nil {
+		in, out := &in.PlainHTTPRegistries, &out.PlainHTTPRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorFailover.
+func (in *MirrorFailover) DeepCopy() *MirrorFailover {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorFailover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceFeatureRule) DeepCopyInto(out *NamespaceFeatureRule) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceFeatureRule.
+func (in *NamespaceFeatureRule) DeepCopy() *NamespaceFeatureRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceFeatureRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryPolicy) DeepCopyInto(out *RegistryPolicy) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Allowed != nil {
+		in, out := &in.Allowed, &out.Allowed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Denied != nil {
+		in, out := &in.Denied, &out.Denied
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryPolicy.
+func (in *RegistryPolicy) DeepCopy() *RegistryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryQuery) DeepCopyInto(out *RegistryQuery) {
+	*out = *in
+	if in.PlainHTTPRegistries != nil {
+		in, out := &in.PlainHTTPRegistries, &out.PlainHTTPRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryQuery.
+func (in *RegistryQuery) DeepCopy() *RegistryQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantPolicy) DeepCopyInto(out *TenantPolicy) {
+	*out = *in
+	if in.AllowedFeatures != nil {
+		in, out := &in.AllowedFeatures, &out.AllowedFeatures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterTrustBundles != nil {
+		in, out := &in.ClusterTrustBundles, &out.ClusterTrustBundles
+		*out = make([]k8s.ClusterTrustBundle, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedEnvVars != nil {
+		in, out := &in.AllowedEnvVars, &out.AllowedEnvVars
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantPolicy.
+func (in *TenantPolicy) DeepCopy() *TenantPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantPolicy)
+	in.DeepCopyInto(out)
+	return out
+}